@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"syscall"
+	"unsafe"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+
+	"go.sbk.wtf/runj/hook"
+	"go.sbk.wtf/runj/jail"
+	"go.sbk.wtf/runj/state"
+)
+
+// loadHooks decodes the hooks passed by runj and removes them from the
+// environment so that they are not inherited by the target program.
+func loadHooks() (*jail.EntrypointHooks, error) {
+	hooks := &jail.EntrypointHooks{}
+	hooksArg, ok := os.LookupEnv(hooksEnv)
+	if !ok {
+		return hooks, nil
+	}
+	os.Unsetenv(hooksEnv)
+	if err := json.Unmarshal([]byte(hooksArg), hooks); err != nil {
+		return nil, fmt.Errorf("hooks: bad configuration: %w", err)
+	}
+	return hooks, nil
+}
+
+// runHooks runs hooks of the named type in order, passing them the state of
+// the container with this process's PID and the given status.  When jailName
+// is set, the hook paths resolve in this process's namespace and each hook is
+// executed inside the named jail by execHook.  The first failure is returned.
+func runHooks(name string, hooks []runtimespec.Hook, s state.Output, status state.Status, jailName string) error {
+	s.PID = os.Getpid()
+	s.Status = string(status)
+	for i := range hooks {
+		h := hooks[i]
+		if jailName != "" {
+			var err error
+			h, err = jailHook(h, jailName)
+			if err != nil {
+				return fmt.Errorf("%s hook %q: %w", name, hooks[i].Path, err)
+			}
+		}
+		if err := hook.Run(&s, &h); err != nil {
+			return fmt.Errorf("%s hook %q: %w", name, hooks[i].Path, err)
+		}
+	}
+	return nil
+}
+
+// jailHook returns a hook that runs this program as the helper implemented by
+// execHook, which executes h inside the named jail
+func jailHook(h runtimespec.Hook, jailName string) (runtimespec.Hook, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return runtimespec.Hook{}, err
+	}
+	argv := h.Args
+	if len(argv) == 0 {
+		argv = []string{h.Path}
+	}
+	// a hook without env inherits the environment, as it does with hook.Run
+	env := h.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	return runtimespec.Hook{
+		Path:    self,
+		Args:    append([]string{self, h.Path}, argv...),
+		Env:     append(slices.Clone(env), hookJailEnv+"="+jailName),
+		Timeout: h.Timeout,
+	}, nil
+}
+
+// execHook implements the helper started for a hook by jailHook, with the
+// arguments HOOK-PATH ARGV...  The hook program is opened before attaching to
+// the jail, so that its path resolves on the host, and then executed inside
+// the jail with fexecve(2).  On success, execHook does not return.
+func execHook(jailName string) (int, error) {
+	os.Unsetenv(hookJailEnv)
+	if len(os.Args) < 3 {
+		return 1, errors.New("usage: runj-entrypoint HOOK-PATH ARGV...")
+	}
+	path := os.Args[1]
+	fd, err := unix.Open(path, unix.O_EXEC|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return 19, fmt.Errorf("hook: failed to open %q: %w", path, err)
+	}
+	j, err := jail.FromName(jailName)
+	if err != nil {
+		return 5, err
+	}
+	if err := j.Attach(); err != nil {
+		return 6, err
+	}
+	if err := fexecve(fd, os.Args[2:], os.Environ()); err != nil {
+		return 20, fmt.Errorf("hook: failed to exec %q: %w", path, err)
+	}
+	return 0, nil
+}
+
+// fexecve replaces this process with the program open at fd
+func fexecve(fd int, argv []string, envv []string) error {
+	argvp, err := syscall.SlicePtrFromStrings(argv)
+	if err != nil {
+		return err
+	}
+	envvp, err := syscall.SlicePtrFromStrings(envv)
+	if err != nil {
+		return err
+	}
+	_, _, errno := unix.Syscall(unix.SYS_FEXECVE, uintptr(fd), uintptr(unsafe.Pointer(&argvp[0])), uintptr(unsafe.Pointer(&envvp[0])))
+	return errno
+}
+
+// openSync opens the socket runj create passed for reporting the outcome of
+// `runj create`.  A nil *os.File is returned when no socket was passed.
+func openSync() (*os.File, error) {
+	syncFdArg := os.Getenv(syncEnv)
+	if syncFdArg == "" {
+		return nil, nil
+	}
+	os.Unsetenv(syncEnv)
+	syncFd, err := strconv.Atoi(syncFdArg)
+	if err != nil {
+		return nil, fmt.Errorf("sync: bad socket fd: %w", err)
+	}
+	// keep the socket from hooks, so that runj create sees it close
+	unix.CloseOnExec(syncFd)
+	return os.NewFile(uintptr(syncFd), "sync"), nil
+}
+
+// awaitSync blocks until runj create signals that the runtime environment is
+// ready
+func awaitSync(sync *os.File) error {
+	b := make([]byte, 1)
+	if _, err := sync.Read(b); err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	return nil
+}
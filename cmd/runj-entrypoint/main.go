@@ -9,16 +9,35 @@ invoked, but blocks until `runj start` is invoked.
 
 Unfortunately, this program works through indirection that is not obvious.  When
 `runj create` is run, it creates a fifo (see mkfifo(2)) and then starts this
-program, passing the jail ID, the path to the fifo, and the program that should
+program, passing the jail name, the path to the fifo, and the program that should
 be invoked as arguments.  This program then opens the fifo for writing, which
 should block to wait for the right time to actually exec into the target
 program.  `runj start` will open the fifo for reading, which unblocks this
 program and the jail process can start.
 
+For the jail's init process, runj-entrypoint attaches to the jail during `runj
+create`, before blocking on the fifo.  `runj create` passes one end of a socket
+(its fd number in the __RUNJ_SYNC environment variable) and writes to it once the
+createRuntime hooks have run.  This program then runs the createContainer hooks,
+attaches to the jail, and reports the result over the socket.  The path of a
+createContainer hook resolves on the host, so each hook is started through this
+program in a helper mode (signalled by the __RUNJ_HOOK_JAIL environment
+variable): the helper opens the hook program, attaches to the jail, and
+fexecve(2)s the program.  The
+startContainer hooks are run once `runj start` opens the fifo.  The hooks and
+the state passed to them are provided as JSON in the __RUNJ_HOOKS environment
+variable.  The fifo lives outside the jail, so its directory is opened before
+attaching and the fifo is opened relative to it.
+
 The above procedure is skipped when secondary processes are started, since there
 is no create/start split involved for these processes and the STDIO of `runj
 extension exec` is used directly.
 
+Settings from the OCI process configuration that must be applied from inside
+the jail (such as the user and groups) are passed to this program as JSON in the
+__RUNJ_PROCESS environment variable.  That variable is removed from the
+environment before the target program is started.
+
 This program exec(2)s to into the final target program.  The sequence of
 exec(2)` preserves the PID so that it can be the target of a future invocation
 of `runj kill`.
@@ -26,16 +45,21 @@ of `runj kill`.
 package main
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"syscall"
 
 	"go.sbk.wtf/runj/jail"
+	"go.sbk.wtf/runj/state"
 
 	"github.com/containerd/console"
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/sys/unix"
 )
 
@@ -47,39 +71,90 @@ func main() {
 	os.Exit(exit)
 }
 
-var errUsage = errors.New("usage: runj-entrypoint JAIL-ID FIFO-PATH PROGRAM [ARGS...]")
+var errUsage = errors.New("usage: runj-entrypoint JAIL-NAME FIFO-PATH PROGRAM [ARGS...]")
 
 const (
 	consoleSocketEnv = "__RUNJ_CONSOLE_SOCKET"
+	processEnv       = "__RUNJ_PROCESS"
+	hooksEnv         = "__RUNJ_HOOKS"
+	syncEnv          = "__RUNJ_SYNC"
+	hookJailEnv      = "__RUNJ_HOOK_JAIL"
 
 	// skipExecFifo signals that the exec fifo sync procedure should be skipped
 	skipExecFifo = "-"
 )
 
-func _main() (int, error) {
-	if len(os.Args) < 4 {
+func _main() (_ int, retErr error) {
+	if name, ok := os.LookupEnv(hookJailEnv); ok {
+		return execHook(name)
+	}
+
+	// a missing PROGRAM is reported once the container is started, so that
+	// `runj create` succeeds for a config without process.args
+	if len(os.Args) < 3 {
 		return 1, errUsage
 	}
 	jid := os.Args[1]
 	fifoPath := os.Args[2]
-	command := os.Args[3]
-	argv := os.Args[4:]
 
-	if err := setupConsole(); err != nil {
+	// report sends an error that keeps the container process from starting
+	// to runj: to `runj create` until the container is created, and to `runj
+	// start` afterwards
+	var report func(error)
+	defer func() {
+		if retErr != nil && report != nil {
+			report(retErr)
+		}
+	}()
+
+	sync, err := openSync()
+	if err != nil {
+		return 15, err
+	}
+	if sync != nil {
+		report = func(err error) {
+			sync.Write([]byte(err.Error()))
+		}
+	}
+
+	process, err := loadProcess()
+	if err != nil {
+		return 10, err
+	}
+
+	hooks, err := loadHooks()
+	if err != nil {
+		return 14, err
+	}
+
+	if err := setupConsole(process.ConsoleSize); err != nil {
 		return 2, err
 	}
 
+	// the fifo is outside the jail, so hold on to its directory in order to
+	// open it after attaching
+	fifoDir := -1
 	if fifoPath != skipExecFifo {
-		// Block until `runj start` is invoked
-		fifofd, err := unix.Open(fifoPath, unix.O_WRONLY|unix.O_CLOEXEC, 0)
+		fifoDir, err = unix.Open(filepath.Dir(fifoPath), unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
 		if err != nil {
-			return 3, fmt.Errorf("failed to open fifo: %w", err)
+			return 3, fmt.Errorf("failed to open fifo directory: %w", err)
 		}
-		if _, err := unix.Write(fifofd, []byte("0")); err != nil {
-			return 4, fmt.Errorf("failed to write to fifo: %w", err)
+	}
+
+	if sync != nil {
+		// Block until `runj create` has set up the runtime environment
+		if err := awaitSync(sync); err != nil {
+			return 16, err
 		}
 	}
 
+	// createContainer hook paths resolve on the host, so the hooks are run
+	// before attaching; each is executed inside the jail
+	err = runHooks("createContainer", hooks.CreateContainer, hooks.State, state.StatusCreating, jid)
+	if err != nil {
+		return 17, err
+	}
+
 	j, err := jail.FromName(jid)
 	if err != nil {
 		return 5, err
@@ -91,12 +166,70 @@ func _main() (int, error) {
 		return 6, err
 	}
 
-	// change to the jail's root
-	err = os.Chdir("/")
+	if sync != nil {
+		// the container is created; let `runj create` exit
+		if _, err := sync.Write([]byte("0")); err != nil {
+			return 16, fmt.Errorf("failed to write to sync socket: %w", err)
+		}
+		sync.Close()
+		report = nil
+	}
+
+	if fifoDir >= 0 {
+		// Block until `runj start` is invoked
+		fifofd, err := unix.Openat(fifoDir, filepath.Base(fifoPath), unix.O_WRONLY|unix.O_CLOEXEC, 0)
+		unix.Close(fifoDir)
+		if err != nil {
+			return 3, fmt.Errorf("failed to open fifo: %w", err)
+		}
+		if _, err := unix.Write(fifofd, []byte("0")); err != nil {
+			return 4, fmt.Errorf("failed to write to fifo: %w", err)
+		}
+		// `runj start` reads until the fifo is closed, which happens on a
+		// successful exec(2) because of O_CLOEXEC.  Anything written after
+		// the initial byte is reported by `runj start` as an error.
+		report = func(err error) {
+			unix.Write(fifofd, []byte(err.Error()))
+		}
+	}
+
+	err = runHooks("startContainer", hooks.StartContainer, hooks.State, state.StatusCreated, "")
+	if err != nil {
+		return 18, err
+	}
+
+	// change to the working directory, which defaults to the jail's root
+	err = setupCwd(process.Cwd)
 	if err != nil {
 		return 7, err
 	}
 
+	// resolve the username with the jail's passwd and group databases
+	u, err := resolveUser(process.User)
+	if err != nil {
+		return 11, err
+	}
+
+	// apply resource limits while still root, which is required to raise a
+	// hard limit
+	err = jail.SetRlimits(process.Rlimits)
+	if err != nil {
+		return 13, err
+	}
+
+	// drop privileges to the configured user; this must happen after attach
+	// as jail_attach(2) requires root
+	err = setupUser(u)
+	if err != nil {
+		return 12, err
+	}
+
+	if len(os.Args) < 4 {
+		return 1, errUsage
+	}
+	command := os.Args[3]
+	argv := os.Args[4:]
+
 	// unix.Exec requires the full path to the supplied command
 	cmdpath, err := exec.LookPath(command)
 	if err != nil {
@@ -109,7 +242,40 @@ func _main() (int, error) {
 	return 0, nil
 }
 
-func setupConsole() error {
+// loadProcess decodes the process configuration passed by runj and removes it
+// from the environment so that it is not inherited by the target program.
+func loadProcess() (*jail.EntrypointProcess, error) {
+	process := &jail.EntrypointProcess{}
+	processArg, ok := os.LookupEnv(processEnv)
+	if !ok {
+		return process, nil
+	}
+	os.Unsetenv(processEnv)
+	if err := json.Unmarshal([]byte(processArg), process); err != nil {
+		return nil, fmt.Errorf("process: bad configuration: %w", err)
+	}
+	return process, nil
+}
+
+// setupCwd changes to the process's working directory.  The path is resolved
+// inside the jail, so this must be called after attaching to the jail.
+func setupCwd(cwd string) error {
+	if cwd == "" {
+		cwd = "/"
+	}
+	err := os.Chdir(cwd)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("cwd: %q does not exist in the jail", cwd)
+	} else if err != nil {
+		return fmt.Errorf("cwd: %w", err)
+	}
+	return nil
+}
+
+// setupConsole allocates a pty when runj passed a console socket, sizes it,
+// sends the control device over the socket, and makes the pty the controlling
+// terminal and STDIO of this process.
+func setupConsole(size *runtimespec.Box) error {
 	socketFdArg := os.Getenv(consoleSocketEnv)
 	if socketFdArg == "" {
 		return nil
@@ -129,6 +295,17 @@ func setupConsole() error {
 	}
 	defer pty.Close()
 
+	// size the pty before handing it out so that the process never observes
+	// a 0x0 window
+	if size != nil {
+		if err := pty.Resize(console.WinSize{
+			Height: uint16(size.Height),
+			Width:  uint16(size.Width),
+		}); err != nil {
+			return fmt.Errorf("console: failed to resize: %w", err)
+		}
+	}
+
 	if err := SendFd(socket, pty.Name(), pty.Fd()); err != nil {
 		return err
 	}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+
+	"go.sbk.wtf/runj/internal/user"
+)
+
+const (
+	passwdPath = "/etc/passwd"
+	groupPath  = "/etc/group"
+)
+
+// resolveUser resolves the username of the OCI process user against the passwd
+// and group databases.  It must be called after attaching to the jail so that
+// the jail's databases are used rather than the host's.
+func resolveUser(u runtimespec.User) (runtimespec.User, error) {
+	if u.Username == "" {
+		return u, nil
+	}
+	passwd, err := openDatabase(passwdPath)
+	if err != nil {
+		return u, err
+	}
+	if passwd != nil {
+		defer passwd.Close()
+	}
+	group, err := openDatabase(groupPath)
+	if err != nil {
+		return u, err
+	}
+	if group != nil {
+		defer group.Close()
+	}
+	return user.Resolve(u, readerOrNil(passwd), readerOrNil(group))
+}
+
+// openDatabase opens a passwd or group file, returning nil if it does not exist
+func openDatabase(path string) (*os.File, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// readerOrNil avoids passing a typed nil *os.File as a non-nil io.Reader
+func readerOrNil(f *os.File) io.Reader {
+	if f == nil {
+		return nil
+	}
+	return f
+}
+
+// setupUser applies the umask, groups, and user IDs from the OCI process
+// configuration to the current process.  The supplementary groups and the GID
+// must be set before the UID, since changing the UID away from root removes the
+// privilege to change groups.
+func setupUser(user runtimespec.User) error {
+	if user.Umask != nil {
+		unix.Umask(int(*user.Umask))
+	}
+	// Before FreeBSD 15, the first entry of the group list is the effective
+	// GID, so the GID leads the list here to keep AdditionalGids intact.
+	groups := make([]int, 0, len(user.AdditionalGids)+1)
+	groups = append(groups, int(user.GID))
+	for _, gid := range user.AdditionalGids {
+		groups = append(groups, int(gid))
+	}
+	if err := unix.Setgroups(groups); err != nil {
+		return fmt.Errorf("user: failed to set groups %v: %w", groups, err)
+	}
+	if err := unix.Setgid(int(user.GID)); err != nil {
+		return fmt.Errorf("user: failed to set gid %d: %w", user.GID, err)
+	}
+	if err := unix.Setuid(int(user.UID)); err != nil {
+		return fmt.Errorf("user: failed to set uid %d: %w", user.UID, err)
+	}
+	return nil
+}
@@ -4,16 +4,19 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strconv"
 
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"go.sbk.wtf/runj/hook"
 	"go.sbk.wtf/runj/jail"
 	"go.sbk.wtf/runj/oci"
+	"go.sbk.wtf/runj/rctl"
+	runjspec "go.sbk.wtf/runj/runtimespec"
 	"go.sbk.wtf/runj/state"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -51,6 +54,7 @@ func createCommand() *cobra.Command {
 		bundle        string
 		consoleSocket string
 		pidFile       string
+		permissive    bool
 	)
 
 	create := &cobra.Command{
@@ -107,6 +111,13 @@ the console's pseudoterminal`)
 		"",
 		`specify a file where the process ID will be
 written`)
+	flags.BoolVar(
+		&permissive,
+		"permissive",
+		false,
+		`report configuration runj cannot apply as
+warnings instead of failing (also enabled by the
+"`+oci.PermissiveAnnotation+`" annotation)`)
 	create.RunE = func(cmd *cobra.Command, args []string) (err error) {
 		disableUsage(cmd)
 		id := args[0]
@@ -138,17 +149,36 @@ written`)
 		if ociConfig.Process == nil {
 			return errors.New("OCI config Process is required")
 		}
-		s.OCIVersion = ociConfig.Version
-		rootPath := filepath.Join(bundle, "root")
-		if ociConfig.Root != nil && ociConfig.Root.Path != "" {
-			rootPath = ociConfig.Root.Path
-			if rootPath[0] != filepath.Separator {
-				rootPath = filepath.Join(bundle, rootPath)
+		var report *oci.Report
+		report, err = oci.Validate(id, ociConfig)
+		if err != nil {
+			return err
+		}
+		if permissive || oci.Permissive(ociConfig) {
+			for _, u := range report.Unsupported {
+				logrus.Warnf("unsupported configuration: %s", u)
 			}
-			ociConfig.Root.Path = rootPath
-		} else {
-			ociConfig.Root = &runtimespec.Root{Path: rootPath}
+		} else if err = report.Err(); err != nil {
+			return err
+		}
+		if ociConfig.Process.Cwd != "" && !filepath.IsAbs(ociConfig.Process.Cwd) {
+			return fmt.Errorf("OCI config Process.Cwd %q must be an absolute path", ociConfig.Process.Cwd)
+		}
+		if err := jail.ValidateRlimits(ociConfig.Process.Rlimits); err != nil {
+			return err
+		}
+		s.OCIVersion = ociConfig.Version
+		if ociConfig.FreeBSD != nil && ociConfig.FreeBSD.Jail != nil && ociConfig.FreeBSD.Jail.Parent != "" {
+			// the container's jail is created as a child of the parent jail
+			s.JailName = jail.Name(ociConfig.FreeBSD.Jail.Parent, id)
 		}
+		// the jail name, and with it the rctl(8) subject, does not depend on
+		// the state root, so the ID may be in use by a container under
+		// another root
+		if _, ferr := jail.FromName(s.Jail()); ferr == nil {
+			return fmt.Errorf("a jail named %q already exists", s.Jail())
+		}
+		resolveRoot(ociConfig, bundle)
 		// console socket validation
 		if ociConfig.Process.Terminal {
 			if consoleSocket == "" {
@@ -163,11 +193,81 @@ written`)
 			return errors.New("console-socket provided but Process.Terminal is false")
 		}
 
+		var ext *runjspec.FreeBSD
+		ext, err = oci.LoadExtension(id)
+		if err != nil {
+			return err
+		}
+		var rules []string
+		rules, err = rctl.Rules(s.Jail(), oci.ResourceLimits(ociConfig, ext))
+		if err != nil {
+			return err
+		}
+
+		if ociConfig.FreeBSD != nil && len(ociConfig.FreeBSD.Devices) > 0 {
+			err = jail.ValidateDevices(ociConfig.FreeBSD.Devices)
+			if err != nil {
+				return err
+			}
+			s.DevfsRuleset, err = jail.CreateDevfsRuleset(cmd.Context(), ociConfig.FreeBSD.Devices)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err == nil {
+					return
+				}
+				jail.DeleteDevfsRuleset(cmd.Context(), s.DevfsRuleset)
+			}()
+			jail.ApplyDevfsRuleset(ociConfig, s.DevfsRuleset)
+		}
+
+		if s.JailName != "" {
+			s.ChildrenMax, err = jail.AllowChildren(ociConfig.FreeBSD.Jail.Parent)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err == nil {
+					return
+				}
+				jail.RestoreChildren(s.ChildrenMax)
+			}()
+		}
+
+		if ociConfig.FreeBSD != nil && ociConfig.FreeBSD.Jail != nil && ociConfig.FreeBSD.Jail.Interface != "" {
+			s.Interface = ociConfig.FreeBSD.Jail.Interface
+			addrs := append(slices.Clone(ociConfig.FreeBSD.Jail.Ip4Addr), ociConfig.FreeBSD.Jail.Ip6Addr...)
+			s.Aliases, err = jail.AddAliases(cmd.Context(), s.Interface, addrs)
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err == nil {
+					return
+				}
+				jail.RemoveAliases(cmd.Context(), s.Interface, s.Aliases)
+			}()
+		}
+
+		err = jail.MountRoot(id, ociConfig)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if err == nil {
+				return
+			}
+			jail.UnmountRoot(id, ociConfig)
+		}()
+
 		jailcfg := &jail.CreateParams{
-			Name:       id,
-			Root:       rootPath,
+			Name:       s.Jail(),
+			Root:       ociConfig.Root.Path,
 			Hostname:   ociConfig.Hostname,
 			Domainname: ociConfig.Domainname,
+
+			DevfsRuleset: s.DevfsRuleset,
 		}
 		if ociConfig.FreeBSD != nil && ociConfig.FreeBSD.Jail != nil {
 			jailcfg.Host = string(ociConfig.FreeBSD.Jail.Host)
@@ -177,18 +277,46 @@ written`)
 			jailcfg.IP6Addr = ociConfig.FreeBSD.Jail.Ip6Addr
 			jailcfg.VNet = string(ociConfig.FreeBSD.Jail.Vnet)
 			jailcfg.VNetInterface = ociConfig.FreeBSD.Jail.VnetInterfaces
+			jailcfg.SysVMsg = string(ociConfig.FreeBSD.Jail.SysVMsg)
+			jailcfg.SysVSem = string(ociConfig.FreeBSD.Jail.SysVSem)
+			jailcfg.SysVShm = string(ociConfig.FreeBSD.Jail.SysVShm)
 			jailcfg.EnforceStatfs = ociConfig.FreeBSD.Jail.EnforceStatfs
+			if allow := ociConfig.FreeBSD.Jail.Allow; allow != nil {
+				jailcfg.Allow = jail.AllowParams{
+					SetHostname:   allow.SetHostname,
+					RawSockets:    allow.RawSockets,
+					Chflags:       allow.Chflags,
+					Quotas:        allow.Quotas,
+					SocketAf:      allow.SocketAf,
+					Mlock:         allow.Mlock,
+					ReservedPorts: allow.ReservedPorts,
+					Suser:         allow.Suser,
+					Mount:         allow.Mount,
+				}
+			}
 		}
 
 		j, err := jail.Create(jailcfg)
 		if err != nil {
 			return err
 		}
+		s.JID = int(j.ID())
 		defer func() {
 			if err != nil {
 				j.Remove()
 			}
 		}()
+		err = rctl.Add(cmd.Context(), rules)
+		s.RctlRules = rules
+		defer func() {
+			if err == nil {
+				return
+			}
+			rctl.Remove(cmd.Context(), s.RctlRules)
+		}()
+		if err != nil {
+			return err
+		}
 		err = jail.Mount(ociConfig)
 		if err != nil {
 			return err
@@ -212,8 +340,17 @@ written`)
 
 		// Setup and start the "runj-entrypoint" helper program in order to
 		// get the container STDIO hooked up properly.
-		var entrypoint *exec.Cmd
-		entrypoint, err = jail.SetupEntrypoint(id, true, ociConfig.Process.Args, ociConfig.Process.Env, consoleSocket)
+		var hooks *jail.EntrypointHooks
+		if ociConfig.Hooks != nil {
+			hooks = &jail.EntrypointHooks{
+				State:           s.Output(),
+				CreateContainer: ociConfig.Hooks.CreateContainer,
+				StartContainer:  ociConfig.Hooks.StartContainer,
+			}
+			hooks.State.Annotations = ociConfig.Annotations
+		}
+		var entrypoint *jail.Entrypoint
+		entrypoint, err = jail.SetupEntrypoint(id, s.Jail(), true, ociConfig.Process, hooks, consoleSocket)
 		if err != nil {
 			return err
 		}
@@ -234,6 +371,16 @@ written`)
 		}
 
 		if ociConfig.Hooks != nil {
+			// prestart hooks are deprecated in favor of createRuntime hooks
+			// and run at the same point, just before them
+			for _, h := range ociConfig.Hooks.Prestart {
+				output := s.Output()
+				output.Annotations = ociConfig.Annotations
+				err = hook.Run(&output, &h)
+				if err != nil {
+					return err
+				}
+			}
 			for _, h := range ociConfig.Hooks.CreateRuntime {
 				output := s.Output()
 				output.Annotations = ociConfig.Annotations
@@ -244,7 +391,28 @@ written`)
 			}
 		}
 
+		// runj-entrypoint attaches to the jail and runs the createContainer
+		// hooks
+		err = entrypoint.Create()
+		if err != nil {
+			return err
+		}
+
 		return nil
 	}
 	return create
 }
+
+// resolveRoot makes the root path in the config absolute, resolving a relative
+// path against the bundle and defaulting to the bundle's "root" directory.
+func resolveRoot(ociConfig *runtimespec.Spec, bundle string) {
+	if ociConfig.Root == nil {
+		ociConfig.Root = &runtimespec.Root{}
+	}
+	if ociConfig.Root.Path == "" {
+		ociConfig.Root.Path = "root"
+	}
+	if !filepath.IsAbs(ociConfig.Root.Path) {
+		ociConfig.Root.Path = filepath.Join(bundle, ociConfig.Root.Path)
+	}
+}
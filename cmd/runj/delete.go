@@ -8,6 +8,7 @@ import (
 	"go.sbk.wtf/runj/hook"
 	"go.sbk.wtf/runj/jail"
 	"go.sbk.wtf/runj/oci"
+	"go.sbk.wtf/runj/rctl"
 	"go.sbk.wtf/runj/state"
 
 	"github.com/spf13/cobra"
@@ -42,20 +43,37 @@ func deleteCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			running, err := jail.IsRunning(cmd.Context(), id, 0)
+			// a created container's only process is runj-entrypoint, which
+			// has attached to the jail and waits for `runj start`; it is
+			// terminated below rather than treated as running
+			if s.Status != state.StatusCreated {
+				running, err := jail.IsRunning(cmd.Context(), s.Jail(), 0)
+				if err != nil {
+					return fmt.Errorf("delete: failed to determine if jail is running: %w", err)
+				}
+				if running && s.Status == state.StatusPaused {
+					return fmt.Errorf("delete: jail %q is paused; kill it before deleting", id)
+				}
+				if running {
+					return fmt.Errorf("delete: jail %q is not stopped", id)
+				}
+			}
+			// removing a jail removes the jails below it, so child containers
+			// must be deleted first
+			children, err := jail.ChildCount(s.Jail())
 			if err != nil {
-				return fmt.Errorf("delete: failed to determine if jail is running: %w", err)
+				return fmt.Errorf("delete: failed to find jail %q: %w", s.Jail(), err)
 			}
-			if running {
-				return fmt.Errorf("delete: jail %q is not stopped", id)
+			if children > 0 {
+				return fmt.Errorf("delete: jail %q has %d child jails; delete them first", s.Jail(), children)
 			}
 			err = jail.CleanupEntrypoint(id)
 			if err != nil {
 				return fmt.Errorf("delete: failed to find entrypoint process: %w", err)
 			}
-			j, err = jail.FromName(id)
+			j, err = jail.FromName(s.Jail())
 			if err != nil {
-				return fmt.Errorf("delete: failed to find jail %q: %w", id, err)
+				return fmt.Errorf("delete: failed to find jail %q: %w", s.Jail(), err)
 			}
 			err = j.Remove()
 			if err != nil {
@@ -68,10 +86,37 @@ func deleteCommand() *cobra.Command {
 			if ociConfig == nil {
 				return errors.New("OCI config is required")
 			}
+			resolveRoot(ociConfig, s.Bundle)
+			if ociConfig.Root.Readonly {
+				// the mounts were layered onto the read-only view
+				ociConfig.Root.Path = jail.ReadonlyRootPath(id)
+			}
 			err = jail.Unmount(ociConfig)
 			if err != nil {
 				return err
 			}
+			err = jail.UnmountRoot(id, ociConfig)
+			if err != nil {
+				return err
+			}
+			err = rctl.Remove(cmd.Context(), s.RctlRules)
+			if err != nil {
+				return err
+			}
+			err = jail.RemoveAliases(cmd.Context(), s.Interface, s.Aliases)
+			if err != nil {
+				return err
+			}
+			err = jail.RestoreChildren(s.ChildrenMax)
+			if err != nil {
+				return err
+			}
+			if s.DevfsRuleset != 0 {
+				err = jail.DeleteDevfsRuleset(cmd.Context(), s.DevfsRuleset)
+				if err != nil {
+					return err
+				}
+			}
 			err = state.Remove(id)
 			if err != nil {
 				return err
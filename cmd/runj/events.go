@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"go.sbk.wtf/runj/jail"
+	"go.sbk.wtf/runj/oci"
+	"go.sbk.wtf/runj/rctl"
+	"go.sbk.wtf/runj/state"
+	"go.sbk.wtf/runj/stats"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// event is a line in the output of the events command, in the format of `runc
+// events`
+type event struct {
+	// Type is "status" for a change of the container's status, with the
+	// container's state as Data, or "stats" for resource usage, with
+	// stats.Stats as Data
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Data any    `json:"data,omitempty"`
+}
+
+// eventsCommand reports the status changes and resource usage of a container
+// as JSON lines.  This is not part of the OCI runtime specification; it
+// follows `runc events`.
+func eventsCommand() *cobra.Command {
+	events := &cobra.Command{
+		Use:   "events <container-id>",
+		Short: "Display container status changes and resource usage",
+		Long: `Display container status changes and resource usage.
+
+Each event is printed as a line of JSON.  A "status" event is printed when the
+container's status changes (and at the start), and a "stats" event with the
+usage reported by "rctl -u" is printed at every interval while the container
+is created, running, or paused.  The command exits once the container has
+stopped or has been deleted.`,
+		Args: cobra.ExactArgs(1),
+	}
+	interval := 5 * time.Second
+	events.Flags().DurationVar(
+		&interval,
+		"interval",
+		5*time.Second,
+		"set the stats collection interval")
+	oneShot := false
+	events.Flags().BoolVar(
+		&oneShot,
+		"stats",
+		false,
+		"display the container's resource usage once and exit")
+	events.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if interval <= 0 {
+			return errors.New("duration interval must be greater than 0")
+		}
+		return nil
+	}
+	events.RunE = func(cmd *cobra.Command, args []string) error {
+		disableUsage(cmd)
+		id := args[0]
+		enc := json.NewEncoder(os.Stdout)
+		s, err := state.Load(id)
+		if err != nil {
+			return err
+		}
+		ociConfig, err := oci.LoadConfig(id)
+		if err != nil {
+			return err
+		}
+		vnet := ociConfig.FreeBSD != nil && ociConfig.FreeBSD.Jail != nil &&
+			ociConfig.FreeBSD.Jail.Vnet == runtimespec.FreeBSDShareNew
+		if oneShot {
+			if err := refreshStatus(cmd.Context(), s); err != nil {
+				return err
+			}
+			if s.Status != state.StatusCreated && s.Status != state.StatusRunning && s.Status != state.StatusPaused {
+				return errors.New("cannot get stats of non-running container")
+			}
+			st, err := collectStats(cmd.Context(), s, vnet)
+			if err != nil {
+				return err
+			}
+			return enc.Encode(event{Type: "stats", ID: id, Data: st})
+		}
+
+		var last state.Status
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := refreshStatus(cmd.Context(), s); err != nil {
+				return err
+			}
+			if s.Status != last {
+				if err := enc.Encode(event{Type: "status", ID: id, Data: s.Output()}); err != nil {
+					return err
+				}
+				last = s.Status
+			}
+			switch s.Status {
+			case state.StatusCreated, state.StatusRunning, state.StatusPaused:
+				st, err := collectStats(cmd.Context(), s, vnet)
+				if err != nil {
+					return err
+				}
+				if err := enc.Encode(event{Type: "stats", ID: id, Data: st}); err != nil {
+					return err
+				}
+			case state.StatusStopped:
+				return nil
+			}
+
+			select {
+			case <-cmd.Context().Done():
+				return cmd.Context().Err()
+			case <-ticker.C:
+			}
+			s, err = state.Load(id)
+			if errors.Is(err, fs.ErrNotExist) {
+				// the container was deleted
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("events: %w", err)
+			}
+		}
+	}
+	return events
+}
+
+// collectStats returns the resource usage of a container, with the counters of
+// its network interfaces when it has its own vnet.  The interface counters are
+// left out, with a warning, when netstat cannot read them.
+func collectStats(ctx context.Context, s *state.State, vnet bool) (*stats.Stats, error) {
+	usage, err := rctl.Usage(ctx, s.Jail())
+	if err != nil {
+		return nil, err
+	}
+	st := stats.FromUsage(usage)
+	if vnet {
+		st.Network, err = jail.InterfaceStats(ctx, s.Jail())
+		if err != nil {
+			logrus.Warnf("interface counters: %v", err)
+		}
+	}
+	return st, nil
+}
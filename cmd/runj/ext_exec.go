@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/spf13/cobra"
@@ -54,14 +55,15 @@ the console's pseudoterminal`)
 		if err != nil {
 			return err
 		}
+		if err := refreshStatus(cmd.Context(), s); err != nil {
+			return err
+		}
+		if s.Status == state.StatusPaused {
+			return errors.New("cannot exec in paused container")
+		}
 		if s.Status != state.StatusRunning {
 			return errors.New("cannot exec non-running container")
 		}
-		if ok, err := jail.IsRunning(cmd.Context(), id, s.PID); !ok {
-			return errors.New("cannot exec non-running container")
-		} else if err != nil {
-			return err
-		}
 
 		var process runtimespec.Process
 		if processJSONFlag != nil && *processJSONFlag != "" {
@@ -82,6 +84,12 @@ the console's pseudoterminal`)
 			process = *ociConfig.Process
 			process.Args = args[1:]
 		}
+		if process.Cwd != "" && !filepath.IsAbs(process.Cwd) {
+			return fmt.Errorf("process cwd %q must be an absolute path", process.Cwd)
+		}
+		if err := jail.ValidateRlimits(process.Rlimits); err != nil {
+			return err
+		}
 		// console socket validation
 		if process.Terminal {
 			if *consoleSocket == "" {
@@ -99,7 +107,7 @@ the console's pseudoterminal`)
 		cmd.SilenceErrors = true
 		// Setup and start the "runj-entrypoint" helper program in order to
 		// get the container STDIO hooked up properly.
-		return jail.ExecEntrypoint(id, process.Args, process.Env, *consoleSocket)
+		return jail.ExecEntrypoint(s.Jail(), &process, *consoleSocket)
 	}
 	return execCmd
 }
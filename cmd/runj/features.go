@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go.sbk.wtf/runj/oci"
+
+	"github.com/spf13/cobra"
+)
+
+// featuresCommand implements the OCI "features" command
+//
+// features
+//
+// This operation MUST print a JSON document describing the features the
+// runtime implements, as specified in the Features Structure section.  A
+// feature that is listed is supported by this build of the runtime, although
+// it may still fail on a host that lacks the required kernel support.
+func featuresCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "features",
+		Short: "Show the features implemented by runj",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			disableUsage(cmd)
+			b, err := json.MarshalIndent(oci.GetFeatures(), "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		},
+	}
+}
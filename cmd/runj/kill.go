@@ -65,26 +65,21 @@ func killCommand() *cobra.Command {
 		if err != nil {
 			return err
 		}
-		if s.Status == state.StatusRunning {
-			if ok, err := jail.IsRunning(cmd.Context(), id, s.PID); err != nil {
-				return err
-			} else if !ok {
-				s.Status = state.StatusStopped
-				if err := s.Save(); err != nil {
-					return err
-				}
-			}
+		if err := refreshStatus(cmd.Context(), s); err != nil {
+			return err
 		}
-		if s.Status != state.StatusRunning {
+		// the processes of a paused container are stopped, but still receive
+		// signals; SIGKILL terminates them without resuming the container
+		if s.Status != state.StatusRunning && s.Status != state.StatusPaused {
 			return errors.New("cannot signal non-running container")
 		}
 		if pid == 0 {
 			pid = s.PID
 		}
 		if all {
-			return jail.KillAll(cmd.Context(), id, signal)
+			return jail.KillAll(cmd.Context(), s.Jail(), signal)
 		}
-		return jail.Kill(cmd.Context(), id, pid, signal)
+		return jail.Kill(cmd.Context(), s.Jail(), pid, signal)
 	}
 	return kill
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"go.sbk.wtf/runj/state"
+
+	"github.com/spf13/cobra"
+)
+
+// listEntry is the JSON format of a container in the output of the list
+// command: the state output with the creation time and jail ID
+type listEntry struct {
+	state.Output
+	Created time.Time `json:"created"`
+	JID     int       `json:"jid"`
+}
+
+// listCommand lists the containers with stored state.  This is not part of the
+// OCI runtime specification; the output follows `runc list`.
+func listCommand() *cobra.Command {
+	list := &cobra.Command{
+		Use:   "list",
+		Short: "List containers",
+		Args:  cobra.NoArgs,
+	}
+	format := "table"
+	list.Flags().StringVarP(
+		&format,
+		"format",
+		"f",
+		"table",
+		`select one of: table or json`)
+	quiet := false
+	list.Flags().BoolVarP(
+		&quiet,
+		"quiet",
+		"q",
+		false,
+		"display only container IDs")
+	list.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if format != "table" && format != "json" {
+			return fmt.Errorf("invalid format %q", format)
+		}
+		return nil
+	}
+	list.RunE = func(cmd *cobra.Command, args []string) error {
+		disableUsage(cmd)
+		ids, err := state.List()
+		if err != nil {
+			return err
+		}
+		entries := make([]listEntry, 0, len(ids))
+		for _, id := range ids {
+			s, err := state.Load(id)
+			if errors.Is(err, fs.ErrNotExist) {
+				// deleted since it was listed
+				continue
+			} else if err != nil {
+				return err
+			}
+			if err := refreshStatus(cmd.Context(), s); err != nil {
+				return err
+			}
+			entries = append(entries, listEntry{
+				Output:  s.Output(),
+				Created: s.Created,
+				JID:     s.JID,
+			})
+		}
+
+		if quiet {
+			for _, e := range entries {
+				fmt.Println(e.ID)
+			}
+			return nil
+		}
+		if format == "json" {
+			b, err := json.Marshal(entries)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 12, 1, 3, ' ', 0)
+		fmt.Fprint(w, "ID\tPID\tSTATUS\tBUNDLE\tCREATED\tJID\n")
+		for _, e := range entries {
+			created := ""
+			if !e.Created.IsZero() {
+				created = e.Created.Format(time.RFC3339Nano)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%d\n",
+				e.ID, e.PID, e.Status, e.Bundle, created, e.JID)
+		}
+		return w.Flush()
+	}
+	return list
+}
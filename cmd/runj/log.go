@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// logFile is the file named by --log, or nil when entries are written to
+// stderr
+var logFile *os.File
+
+// setupLog directs log entries to the file at path, or to stderr when path is
+// empty, in the named format.  As with runc, the json format writes one object
+// per line with the fields "level", "msg", and "time".
+func setupLog(path, format string) error {
+	switch format {
+	case logFormatText:
+		if path == "" {
+			logrus.SetFormatter(stderrFormatter{})
+		} else {
+			logrus.SetFormatter(&logrus.TextFormatter{DisableQuote: true})
+		}
+	case logFormatJSON:
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return fmt.Errorf("unknown log format %q (must be %q or %q)", format, logFormatText, logFormatJSON)
+	}
+	if path == "" {
+		logrus.SetOutput(os.Stderr)
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_SYNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	logFile = f
+	logrus.SetOutput(f)
+	return nil
+}
+
+// stderrFormatter formats text entries written to stderr as "level: message",
+// without the timestamp and quoting of logrus.TextFormatter
+type stderrFormatter struct{}
+
+func (stderrFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	return []byte(e.Level.String() + ": " + e.Message + "\n"), nil
+}
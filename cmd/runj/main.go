@@ -5,25 +5,72 @@ import (
 	"os/exec"
 
 	"go.sbk.wtf/runj"
+	"go.sbk.wtf/runj/state"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
+// rootEnv is the environment variable that sets the state root when the --root
+// flag is not given
+const rootEnv = "RUNJ_ROOT"
+
 func main() {
 	rootCmd := &cobra.Command{
 		Use:     "runj <command>",
 		Short:   "runj is a skeleton OCI runtime for FreeBSD",
 		Version: runj.Version(),
 	}
+	root := os.Getenv(rootEnv)
+	if root == "" {
+		root = state.DefaultRoot
+	}
+	rootCmd.PersistentFlags().StringVar(
+		&root,
+		"root",
+		root,
+		`directory for storing container state; defaults to the value of
+the `+rootEnv+` environment variable if it is set`)
+	logPath := ""
+	rootCmd.PersistentFlags().StringVar(
+		&logPath,
+		"log",
+		"",
+		`file to which log entries, including errors, are appended; entries
+are written to stderr if not set`)
+	logFormat := logFormatText
+	rootCmd.PersistentFlags().StringVar(
+		&logFormat,
+		"log-format",
+		logFormatText,
+		`format of log entries, either "text" or "json"`)
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := setupLog(logPath, logFormat); err != nil {
+			return err
+		}
+		return state.SetRoot(root)
+	}
 	rootCmd.AddCommand(stateCommand())
 	rootCmd.AddCommand(createCommand())
 	rootCmd.AddCommand(startCommand())
 	rootCmd.AddCommand(killCommand())
+	rootCmd.AddCommand(pauseCommand())
+	rootCmd.AddCommand(resumeCommand())
 	rootCmd.AddCommand(deleteCommand())
+	rootCmd.AddCommand(updateCommand())
+	rootCmd.AddCommand(listCommand())
+	rootCmd.AddCommand(psCommand())
+	rootCmd.AddCommand(eventsCommand())
+	rootCmd.AddCommand(featuresCommand())
 	rootCmd.AddCommand(extCommand())
 	rootCmd.AddCommand(demoCommand())
 	err := rootCmd.Execute()
 	if err != nil {
+		// cobra prints the error to stderr; callers passing --log read it
+		// from the log instead, so it is also written there
+		if logPath != "" && (logFile != nil || setupLog(logPath, logFormat) == nil) {
+			logrus.Error(err)
+		}
 		code := 1
 		if e, ok := err.(*exec.ExitError); ok {
 			code = e.ExitCode()
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+
+	"go.sbk.wtf/runj/jail"
+	"go.sbk.wtf/runj/state"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/unix"
+)
+
+// pauseCommand implements the "pause" command
+//
+// pause <container-id>
+//
+// Extension: pause is not defined by the OCI runtime specification; it matches
+// the runc command of the same name.
+func pauseCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause <container-id>",
+		Short: "Suspend all processes in a container",
+		Long: `Suspend all processes in a running container by sending them SIGSTOP,
+repeating the signal until every process is stopped.  The container is recorded
+as paused until it is resumed with "runj resume".`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			disableUsage(cmd)
+			s, err := state.Load(args[0])
+			if err != nil {
+				return err
+			}
+			if err := refreshStatus(cmd.Context(), s); err != nil {
+				return err
+			}
+			if s.Status == state.StatusPaused {
+				return errors.New("container is already paused")
+			}
+			if s.Status != state.StatusRunning {
+				return errors.New("cannot pause non-running container")
+			}
+			if err := jail.StopAll(cmd.Context(), s.Jail()); err != nil {
+				// do not leave the container partially stopped
+				jail.KillAll(cmd.Context(), s.Jail(), unix.SIGCONT)
+				return err
+			}
+			s.Status = state.StatusPaused
+			return s.Save()
+		},
+	}
+}
+
+// resumeCommand implements the "resume" command
+//
+// resume <container-id>
+//
+// Extension: resume is not defined by the OCI runtime specification; it
+// matches the runc command of the same name.
+func resumeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume <container-id>",
+		Short: "Resume all processes in a paused container",
+		Long: `Resume all processes in a container paused by "runj pause" by sending them
+SIGCONT.  Processes that were already stopped before the container was paused
+are continued as well.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			disableUsage(cmd)
+			s, err := state.Load(args[0])
+			if err != nil {
+				return err
+			}
+			if err := refreshStatus(cmd.Context(), s); err != nil {
+				return err
+			}
+			if s.Status != state.StatusPaused {
+				return errors.New("cannot resume non-paused container")
+			}
+			if err := jail.KillAll(cmd.Context(), s.Jail(), unix.SIGCONT); err != nil {
+				return err
+			}
+			s.Status = state.StatusRunning
+			return s.Save()
+		},
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"go.sbk.wtf/runj/jail"
+	"go.sbk.wtf/runj/state"
+
+	"github.com/spf13/cobra"
+)
+
+// psCommand lists the processes running in a container.  This is not part of
+// the OCI runtime specification; it follows `runc ps`.
+func psCommand() *cobra.Command {
+	ps := &cobra.Command{
+		Use:   "ps <container-id>",
+		Short: "List the processes running in a container",
+		Args:  cobra.ExactArgs(1),
+	}
+	format := "table"
+	ps.Flags().StringVarP(
+		&format,
+		"format",
+		"f",
+		"table",
+		`select one of: table or json`)
+	ps.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if format != "table" && format != "json" {
+			return fmt.Errorf("invalid format %q", format)
+		}
+		return nil
+	}
+	ps.RunE = func(cmd *cobra.Command, args []string) error {
+		disableUsage(cmd)
+		id := args[0]
+		s, err := state.Load(id)
+		if err != nil {
+			return err
+		}
+		if err := refreshStatus(cmd.Context(), s); err != nil {
+			return err
+		}
+		if s.Status != state.StatusCreated && s.Status != state.StatusRunning && s.Status != state.StatusPaused {
+			return errors.New("cannot list processes of non-running container")
+		}
+		processes, err := jail.Processes(cmd.Context(), s.Jail())
+		if err != nil {
+			return err
+		}
+
+		if format == "json" {
+			b, err := json.Marshal(processes)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(b))
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 8, 1, 3, ' ', 0)
+		fmt.Fprint(w, "PID\tTT\tSTAT\tTIME\tCOMMAND\n")
+		for _, p := range processes {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", p.PID, p.Terminal, p.State, p.CPUTime, p.Command)
+		}
+		return w.Flush()
+	}
+	return ps
+}
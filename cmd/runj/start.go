@@ -3,10 +3,12 @@ package main
 import (
 	"errors"
 
+	"go.sbk.wtf/runj/hook"
 	"go.sbk.wtf/runj/jail"
 	"go.sbk.wtf/runj/oci"
 	"go.sbk.wtf/runj/state"
 
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -22,6 +24,9 @@ import (
 //
 // runc's implementation of the start command exits immediately after starting
 // the container's process.  This does not appear to be specified in the spec.
+//
+// The poststart hooks are run after the container's process has started.  A
+// failing poststart hook is logged as a warning and does not fail the command.
 func startCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "start <container-id>",
@@ -43,7 +48,7 @@ func startCommand() *cobra.Command {
 				return err
 			}
 			if s.Status == state.StatusRunning {
-				if ok, err := jail.IsRunning(cmd.Context(), id, s.PID); ok {
+				if ok, err := jail.IsRunning(cmd.Context(), s.Jail(), s.PID); ok {
 					return errors.New("cannot start already running container")
 				} else if err != nil {
 					return err
@@ -51,10 +56,32 @@ func startCommand() *cobra.Command {
 			}
 			err = jail.AwaitFifoOpen(cmd.Context(), id)
 			if err != nil {
+				// runj-entrypoint exits when it fails to start the
+				// container process
+				if ok, _ := jail.IsRunning(cmd.Context(), s.Jail(), s.PID); !ok {
+					s.Status = state.StatusStopped
+					s.Save()
+				}
 				return err
 			}
 			s.Status = state.StatusRunning
-			return s.Save()
+			err = s.Save()
+			if err != nil {
+				return err
+			}
+
+			// the spec requires poststart hook failures to be logged
+			// without affecting the container
+			if ociConfig.Hooks != nil {
+				for _, h := range ociConfig.Hooks.Poststart {
+					output := s.Output()
+					output.Annotations = ociConfig.Annotations
+					if err := hook.Run(&output, &h); err != nil {
+						logrus.Warnf("poststart hook %q: %v", h.Path, err)
+					}
+				}
+			}
+			return nil
 		},
 	}
 }
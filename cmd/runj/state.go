@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 
-	"go.sbk.wtf/runj/jail"
 	"go.sbk.wtf/runj/state"
 
 	"github.com/spf13/cobra"
@@ -62,19 +61,8 @@ func stateCommand() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if s.Status == state.StatusRunning {
-				ok, err := jail.IsRunning(cmd.Context(), id, s.PID)
-				if err != nil {
-					return err
-				}
-				if !ok {
-					s.Status = state.StatusStopped
-					s.PID = 0
-					err = s.Save()
-					if err != nil {
-						return err
-					}
-				}
+			if err := refreshStatus(cmd.Context(), s); err != nil {
+				return err
 			}
 			b, err := json.MarshalIndent(s.Output(), "", "  ")
 			if err != nil {
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+
+	"go.sbk.wtf/runj/jail"
+	"go.sbk.wtf/runj/state"
+)
+
+// refreshStatus checks the stored status of a running or paused container
+// against the kernel and records it as stopped if its process has exited
+func refreshStatus(ctx context.Context, s *state.State) error {
+	if s.Status != state.StatusRunning && s.Status != state.StatusPaused {
+		return nil
+	}
+	ok, err := jail.IsRunning(ctx, s.Jail(), s.PID)
+	if err != nil || ok {
+		return err
+	}
+	s.Status = state.StatusStopped
+	s.PID = 0
+	return s.Save()
+}
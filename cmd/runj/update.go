@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.sbk.wtf/runj/jail"
+	"go.sbk.wtf/runj/oci"
+	"go.sbk.wtf/runj/rctl"
+	runjspec "go.sbk.wtf/runj/runtimespec"
+	"go.sbk.wtf/runj/state"
+
+	"github.com/spf13/cobra"
+)
+
+// updateCommand changes the resource limits and jail parameters of an existing
+// container.  This is not part of the OCI runtime specification; it follows
+// the `runc update` command, and accepts the same resources file.
+//
+// update <container-id> --resources <path>
+func updateCommand() *cobra.Command {
+	update := &cobra.Command{
+		Use:   "update <container-id>",
+		Short: "Update the resource limits of a container",
+		Long: `Update the resource limits of a container.
+
+The resources file is a JSON object holding Linux resources (as read by
+"runc update --resources"), which are translated into rctl(8) limits,
+along with the FreeBSD-specific "limits" and "jail" fields.  See the
+runj documentation for the format.`,
+		Args: cobra.ExactArgs(1),
+	}
+	resources := ""
+	update.Flags().StringVarP(
+		&resources,
+		"resources",
+		"r",
+		"",
+		`path to the file containing the resources to
+update, or "-" to read from standard input`)
+	update.MarkFlagRequired("resources")
+	update.RunE = func(cmd *cobra.Command, args []string) error {
+		disableUsage(cmd)
+		id := args[0]
+		u, err := readUpdate(resources)
+		if err != nil {
+			return err
+		}
+		limits, report := oci.UpdateLimits(u)
+		if err := report.Err(); err != nil {
+			return err
+		}
+		s, err := state.Load(id)
+		if err != nil {
+			return err
+		}
+		if err := refreshStatus(cmd.Context(), s); err != nil {
+			return err
+		}
+		if s.Status != state.StatusCreated && s.Status != state.StatusRunning && s.Status != state.StatusPaused {
+			return errors.New("cannot update non-running container")
+		}
+
+		if u.Jail != nil {
+			params := &jail.UpdateParams{EnforceStatfs: u.Jail.EnforceStatfs}
+			if allow := u.Jail.Allow; allow != nil {
+				params.Allow = jail.AllowParams{
+					SetHostname:   allow.SetHostname,
+					RawSockets:    allow.RawSockets,
+					Chflags:       allow.Chflags,
+					Quotas:        allow.Quotas,
+					SocketAf:      allow.SocketAf,
+					Mlock:         allow.Mlock,
+					ReservedPorts: allow.ReservedPorts,
+					Suser:         allow.Suser,
+					Mount:         allow.Mount,
+				}
+			}
+			if err := jail.Update(s.Jail(), params); err != nil {
+				return fmt.Errorf("update: %w", err)
+			}
+		}
+
+		if len(limits) == 0 {
+			return nil
+		}
+		rules, err := rctl.Rules(s.Jail(), limits)
+		if err != nil {
+			return err
+		}
+		// the rules in place are recorded even when an update fails part way
+		// through, so that delete removes exactly the rules that were added
+		s.RctlRules, err = rctl.Update(cmd.Context(), s.RctlRules, rules)
+		if saveErr := s.Save(); err == nil {
+			err = saveErr
+		}
+		return err
+	}
+	return update
+}
+
+// readUpdate reads the resources file given to the update command
+func readUpdate(path string) (*runjspec.Update, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("update: failed to read resources: %w", err)
+	}
+	u := &runjspec.Update{}
+	if err := json.Unmarshal(data, u); err != nil {
+		return nil, fmt.Errorf("update: failed to parse resources: %w", err)
+	}
+	return u, nil
+}
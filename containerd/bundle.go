@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
@@ -58,6 +59,59 @@ var incompatibleLinuxMounts = map[string]specs.Mount{
 // filterIncompatibleLinuxMounts removes Linux-specific default mounts that
 // might be present in a containerd-generated OCI bundle
 func filterIncompatibleLinuxMounts(bundle string) error {
+	return updateConfig(bundle, func(spec *specs.Spec) bool {
+		mounts := make([]specs.Mount, 0)
+		for _, m := range spec.Mounts {
+			if toFilter, ok := incompatibleLinuxMounts[m.Destination]; ok {
+				if equalMounts(m, toFilter) {
+					continue
+				}
+			}
+			mounts = append(mounts, m)
+		}
+		if len(spec.Mounts) == len(mounts) {
+			return false
+		}
+		spec.Mounts = mounts
+		return true
+	})
+}
+
+// filterLinuxDefaults removes the Linux-specific settings containerd generates
+// for every container as part of its default spec, regardless of the target
+// platform.  runj rejects settings it cannot apply, so leaving these in place
+// would make every container created through containerd fail.
+func filterLinuxDefaults(bundle string) error {
+	return updateConfig(bundle, func(spec *specs.Spec) bool {
+		changed := false
+		if spec.Process != nil && (spec.Process.Capabilities != nil || spec.Process.NoNewPrivileges) {
+			spec.Process.Capabilities = nil
+			spec.Process.NoNewPrivileges = false
+			changed = true
+		}
+		if spec.Linux == nil {
+			return changed
+		}
+		spec.Linux.Namespaces = nil
+		spec.Linux.MaskedPaths = nil
+		spec.Linux.ReadonlyPaths = nil
+		spec.Linux.CgroupsPath = ""
+		if spec.Linux.Resources != nil {
+			spec.Linux.Resources.Devices = nil
+			if reflect.ValueOf(*spec.Linux.Resources).IsZero() {
+				spec.Linux.Resources = nil
+			}
+		}
+		if reflect.ValueOf(*spec.Linux).IsZero() {
+			spec.Linux = nil
+		}
+		return true
+	})
+}
+
+// updateConfig loads the config in the bundle, calls update, and writes the
+// config back if update reports that it changed it
+func updateConfig(bundle string, update func(spec *specs.Spec) bool) error {
 	if bundle == "" {
 		return nil
 	}
@@ -71,20 +125,9 @@ func filterIncompatibleLinuxMounts(bundle string) error {
 	if err != nil {
 		return err
 	}
-
-	mounts := make([]specs.Mount, 0)
-	for _, m := range spec.Mounts {
-		if toFilter, ok := incompatibleLinuxMounts[m.Destination]; ok {
-			if equalMounts(m, toFilter) {
-				continue
-			}
-		}
-		mounts = append(mounts, m)
-	}
-	if len(spec.Mounts) == len(mounts) {
+	if !update(spec) {
 		return nil
 	}
-	spec.Mounts = mounts
 	out, err := json.Marshal(spec)
 	if err != nil {
 		return err
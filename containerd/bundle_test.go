@@ -150,3 +150,79 @@ func TestEqualMounts(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterLinuxDefaults(t *testing.T) {
+	tests := []struct {
+		name string
+		in   specs.Spec
+		out  specs.Spec
+	}{{
+		name: "empty",
+	}, {
+		name: "defaults",
+		in: specs.Spec{
+			Process: &specs.Process{
+				Args:            []string{"/bin/sh"},
+				NoNewPrivileges: true,
+				Capabilities:    &specs.LinuxCapabilities{Bounding: []string{"CAP_CHOWN"}},
+			},
+			Linux: &specs.Linux{
+				Namespaces:    []specs.LinuxNamespace{{Type: specs.PIDNamespace}},
+				MaskedPaths:   []string{"/proc/kcore"},
+				ReadonlyPaths: []string{"/proc/sys"},
+				CgroupsPath:   "/default/example",
+				Resources: &specs.LinuxResources{
+					Devices: []specs.LinuxDeviceCgroup{{Allow: false, Access: "rwm"}},
+				},
+			},
+		},
+		out: specs.Spec{
+			Process: &specs.Process{Args: []string{"/bin/sh"}},
+		},
+	}, {
+		name: "other linux settings",
+		in: specs.Spec{
+			Linux: &specs.Linux{
+				CgroupsPath: "/default/example",
+				Sysctl:      map[string]string{"net.ipv4.ip_forward": "1"},
+				Resources: &specs.LinuxResources{
+					Devices: []specs.LinuxDeviceCgroup{{Allow: false, Access: "rwm"}},
+					Pids:    &specs.LinuxPids{Limit: ptr(int64(10))},
+				},
+			},
+		},
+		out: specs.Spec{
+			Linux: &specs.Linux{
+				Sysctl: map[string]string{"net.ipv4.ip_forward": "1"},
+				Resources: &specs.LinuxResources{
+					Pids: &specs.LinuxPids{Limit: ptr(int64(10))},
+				},
+			},
+		},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			inBytes, err := json.Marshal(&tc.in)
+			require.NoError(t, err, "failed to marshal input")
+			configJSON := filepath.Join(dir, "config.json")
+			err = os.WriteFile(configJSON, inBytes, 0644)
+			require.NoError(t, err, "failed to write config.json")
+
+			err = filterLinuxDefaults(dir)
+			require.NoError(t, err, "failed filter")
+
+			out := specs.Spec{}
+			outBytes, err := os.ReadFile(configJSON)
+			require.NoError(t, err, "failed to read config.json")
+			err = json.Unmarshal(outBytes, &out)
+			require.NoError(t, err, "failed to unmarshal config.json")
+
+			assert.Equal(t, tc.out, out)
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -1,23 +1,159 @@
 package containerd
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"sync"
 
+	"go.sbk.wtf/runj/oci"
+	"go.sbk.wtf/runj/stats"
+
 	"github.com/containerd/console"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
 	"github.com/containerd/containerd/v2/pkg/sys/reaper"
 	runc "github.com/containerd/go-runc"
 	"github.com/containerd/log"
 	"github.com/sirupsen/logrus"
 )
 
+// runjRoot is the directory under which the shim keeps a runj state root for
+// each containerd namespace
+const runjRoot = "/var/lib/runj/containerd"
+
+// runjLogFilename is the file, in the bundle directory the shim runs in, to
+// which the log entries of runj are appended
+const runjLogFilename = "log.json"
+
+// runjCommand returns a command running runj with args.  The state root is
+// chosen by the namespace of ctx, so that each namespace lists only its own
+// containers.  Jail names are not scoped by the root, so runj refuses to create
+// a container whose ID is in use in another namespace.  runj logs to
+// runjLogFilename in the working directory.
+func runjCommand(ctx context.Context, args ...string) *exec.Cmd {
+	path, err := runjLogPath()
+	if err != nil {
+		path = ""
+	}
+	return runjCommandWithLog(ctx, path, args...)
+}
+
+// runjLoggedCommand is like runjCommand, but runj logs to a file of its own so
+// that the errors of this command can be read back with (*runjLog).error.  The
+// log must be closed once the command has finished.
+func runjLoggedCommand(ctx context.Context, args ...string) (*exec.Cmd, *runjLog) {
+	l := newRunjLog()
+	if l == nil {
+		return runjCommand(ctx, args...), nil
+	}
+	return runjCommandWithLog(ctx, l.path, args...), l
+}
+
+func runjCommandWithLog(ctx context.Context, logPath string, args ...string) *exec.Cmd {
+	if ns, ok := namespaces.Namespace(ctx); ok {
+		args = append([]string{"--root", filepath.Join(runjRoot, ns)}, args...)
+	}
+	if logPath != "" {
+		args = append([]string{"--log", logPath, "--log-format", "json"}, args...)
+	}
+	return exec.CommandContext(ctx, "runj", args...)
+}
+
+func runjLogPath() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(wd, runjLogFilename), nil
+}
+
+// runjLog is the log file of a single runj command.  Keeping each command in
+// its own file means that the errors read back belong to that command, even
+// while other commands run for the same container.
+type runjLog struct {
+	path string
+}
+
+// newRunjLog creates an empty log file in the temporary directory.  nil is
+// returned when the file cannot be created.
+func newRunjLog() *runjLog {
+	f, err := os.CreateTemp("", "runj-log-*.json")
+	if err != nil {
+		return nil
+	}
+	f.Close()
+	return &runjLog{path: f.Name()}
+}
+
+// error adds the last error logged by the command to err, the error from
+// running it
+func (l *runjLog) error(err error) error {
+	if l == nil {
+		return err
+	}
+	f, ferr := os.Open(l.path)
+	if ferr != nil {
+		return err
+	}
+	defer f.Close()
+	if msg := lastLoggedError(f); msg != "" {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	return err
+}
+
+// close appends the entries of the command to runjLogFilename, so that the
+// shim keeps a single log of every command, and removes the file
+func (l *runjLog) close() {
+	if l == nil {
+		return
+	}
+	defer os.Remove(l.path)
+	src, err := os.Open(l.path)
+	if err != nil {
+		return
+	}
+	defer src.Close()
+	path, err := runjLogPath()
+	if err != nil {
+		return
+	}
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return
+	}
+	defer dst.Close()
+	io.Copy(dst, src)
+}
+
+// lastLoggedError returns the message of the last error-level entry in a log
+// written with --log-format json.  Lines that cannot be decoded are skipped.
+func lastLoggedError(r io.Reader) string {
+	var msg string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var entry struct {
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if entry.Level == "error" || entry.Level == "fatal" {
+			msg = entry.Msg
+		}
+	}
+	return msg
+}
+
 // execCreate runs the "create" subcommand for runj
 func execCreate(ctx context.Context, id, bundle string, stdin io.Reader, stdout io.Writer, stderr io.Writer, terminal bool) (console.Console, error) {
 	args := []string{"create", id, bundle}
@@ -33,7 +169,8 @@ func execCreate(ctx context.Context, id, bundle string, stdin io.Reader, stdout
 		args = append(args, "--console-socket", socket.Path())
 	}
 
-	cmd := exec.CommandContext(ctx, "runj", args...)
+	cmd, rlog := runjLoggedCommand(ctx, args...)
+	defer rlog.close()
 	cmd.Stdin = stdin
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
@@ -80,7 +217,7 @@ func execCreate(ctx context.Context, id, bundle string, stdin io.Reader, stdout
 	}
 	if ret != 0 {
 		log.G(ctx).WithField("exit", ret).Error("runj create failed")
-		return nil, errors.New("runj create failed")
+		return nil, rlog.error(errors.New("runj create failed"))
 	}
 	if socket != nil {
 		ret := <-ready
@@ -133,14 +270,15 @@ type ociState struct {
 
 // execState runs the "state" subcommand for runj
 func execState(ctx context.Context, id string) (*ociState, error) {
-	cmd := exec.CommandContext(ctx, "runj", "state", id)
+	cmd, rlog := runjLoggedCommand(ctx, "state", id)
+	defer rlog.close()
 	b, err := combinedOutput(cmd)
 	if err != nil {
 		log.G(ctx).
 			WithError(err).
 			WithField("output", string(b)).
 			WithField("id", id).Error("runj state failed")
-		return nil, err
+		return nil, rlog.error(err)
 	}
 	s := &ociState{}
 	err = json.Unmarshal(b, s)
@@ -149,11 +287,12 @@ func execState(ctx context.Context, id string) (*ociState, error) {
 
 // execDelete runs the "delete" subcommand for runj
 func execDelete(ctx context.Context, id string) error {
-	cmd := exec.CommandContext(ctx, "runj", "delete", id)
+	cmd, rlog := runjLoggedCommand(ctx, "delete", id)
+	defer rlog.close()
 	b, err := combinedOutput(cmd)
 	if err != nil {
 		log.G(ctx).WithError(err).WithField("output", string(b)).WithField("id", id).Error("runj delete failed")
-		return err
+		return rlog.error(err)
 	}
 	return nil
 }
@@ -167,26 +306,127 @@ func execKill(ctx context.Context, id string, signal string, all bool, pid int)
 	if pid != 0 {
 		args = append(args, "--pid", strconv.Itoa(pid))
 	}
-	cmd := exec.CommandContext(ctx, "runj", args...)
+	cmd, rlog := runjLoggedCommand(ctx, args...)
+	defer rlog.close()
 	b, err := combinedOutput(cmd)
 	if err != nil {
 		log.G(ctx).WithError(err).WithField("output", string(b)).WithField("id", id).Error("runj kill failed")
-		return err
+		return rlog.error(err)
 	}
 	return nil
 }
 
 // execStart runs the "start" subcommand for runj
 func execStart(ctx context.Context, id string) error {
-	cmd := exec.CommandContext(ctx, "runj", "start", id)
+	cmd, rlog := runjLoggedCommand(ctx, "start", id)
+	defer rlog.close()
 	b, err := combinedOutput(cmd)
 	if err != nil {
 		log.G(ctx).WithError(err).WithField("output", string(b)).WithField("id", id).Error("runj start failed")
-		return err
+		return rlog.error(err)
+	}
+	return nil
+}
+
+// execPause runs the "pause" subcommand for runj
+func execPause(ctx context.Context, id string) error {
+	cmd, rlog := runjLoggedCommand(ctx, "pause", id)
+	defer rlog.close()
+	b, err := combinedOutput(cmd)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("output", string(b)).WithField("id", id).Error("runj pause failed")
+		return rlog.error(err)
+	}
+	return nil
+}
+
+// execResume runs the "resume" subcommand for runj
+func execResume(ctx context.Context, id string) error {
+	cmd, rlog := runjLoggedCommand(ctx, "resume", id)
+	defer rlog.close()
+	b, err := combinedOutput(cmd)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("output", string(b)).WithField("id", id).Error("runj resume failed")
+		return rlog.error(err)
 	}
 	return nil
 }
 
+// execUpdate runs the "update" subcommand for runj
+func execUpdate(ctx context.Context, id, resourcesFilename string) error {
+	cmd, rlog := runjLoggedCommand(ctx, "update", id, "--resources", resourcesFilename)
+	defer rlog.close()
+	b, err := combinedOutput(cmd)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("output", string(b)).WithField("id", id).Error("runj update failed")
+		return rlog.error(err)
+	}
+	return nil
+}
+
+// psProcess is the part of a process listed by "runj ps" used by the shim
+type psProcess struct {
+	PID int `json:"pid"`
+}
+
+// execPs runs the "ps" subcommand for runj
+func execPs(ctx context.Context, id string) ([]psProcess, error) {
+	cmd, rlog := runjLoggedCommand(ctx, "ps", "--format", "json", id)
+	defer rlog.close()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	b, err := output(cmd)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("output", stderr.String()).WithField("id", id).Error("runj ps failed")
+		return nil, rlog.error(err)
+	}
+	var processes []psProcess
+	err = json.Unmarshal(b, &processes)
+	return processes, err
+}
+
+// execStats runs the "events --stats" subcommand for runj
+func execStats(ctx context.Context, id string) (*stats.Stats, error) {
+	cmd, rlog := runjLoggedCommand(ctx, "events", "--stats", id)
+	defer rlog.close()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	b, err := output(cmd)
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("output", stderr.String()).WithField("id", id).Error("runj events failed")
+		return nil, rlog.error(err)
+	}
+	var e struct {
+		Type string       `json:"type"`
+		Data *stats.Stats `json:"data"`
+	}
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, err
+	}
+	if e.Type != "stats" || e.Data == nil {
+		return nil, fmt.Errorf("unexpected event %q from runj events", e.Type)
+	}
+	return e.Data, nil
+}
+
+// execFeatures runs the "features" subcommand for runj.  It is used outside of
+// a bundle directory, so runj is run without a log file.
+func execFeatures(ctx context.Context) (*oci.Features, error) {
+	cmd := exec.CommandContext(ctx, "runj", "features")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	b, err := cmd.Output()
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("output", stderr.String()).Error("runj features failed")
+		return nil, err
+	}
+	f := &oci.Features{}
+	if err := json.Unmarshal(b, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
 // execExec runs the "extension exec" subcommand for runj
 func execExec(ctx context.Context, id, processJSONFilename string, stdin io.Reader, stdout io.Writer, stderr io.Writer, terminal bool) (int, console.Console, error) {
 	args := []string{"extension", "exec", id, "--process", processJSONFilename}
@@ -202,7 +442,7 @@ func execExec(ctx context.Context, id, processJSONFilename string, stdin io.Read
 		args = append(args, "--console-socket", socket.Path())
 	}
 
-	cmd := exec.CommandContext(ctx, "runj", args...)
+	cmd := runjCommand(ctx, args...)
 	cmd.Stdin = stdin
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
@@ -245,3 +485,19 @@ func combinedOutput(cmd *exec.Cmd) ([]byte, error) {
 	b := stdout.Bytes()
 	return b, err
 }
+
+// output runs cmd like combinedOutput, but returns only its standard output and
+// fails when cmd exits with a non-zero status
+func output(cmd *exec.Cmd) ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	ec, err := reaper.Default.Start(cmd)
+	if err != nil {
+		return nil, err
+	}
+	status, err := reaper.Default.Wait(cmd, ec)
+	if err == nil && status != 0 {
+		err = fmt.Errorf("exit status %d", status)
+	}
+	return stdout.Bytes(), err
+}
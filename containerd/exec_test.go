@@ -0,0 +1,63 @@
+package containerd
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastLoggedError(t *testing.T) {
+	tests := []struct {
+		name string
+		log  string
+		msg  string
+	}{{
+		name: "empty",
+		log:  "",
+		msg:  "",
+	}, {
+		name: "no errors",
+		log:  `{"level":"warning","msg":"unsupported configuration: linux.sysctl","time":"2026-10-17T10:00:00Z"}` + "\n",
+		msg:  "",
+	}, {
+		name: "last error",
+		log: `{"level":"error","msg":"cannot pause non-running container","time":"2026-10-17T10:00:00Z"}
+{"level":"warning","msg":"interface counters: exit status 1","time":"2026-10-17T10:00:01Z"}
+{"level":"error","msg":"cannot exec in paused container","time":"2026-10-17T10:00:02Z"}
+`,
+		msg: "cannot exec in paused container",
+	}, {
+		name: "malformed lines",
+		log: `{"level":"error","msg":"delete: jail \"abc\" is not stopped","time":"2026-10-17T10:00:00Z"}
+not json
+{"level":"error","msg":`,
+		msg: `delete: jail "abc" is not stopped`,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.msg, lastLoggedError(strings.NewReader(tc.log)))
+		})
+	}
+}
+
+func TestRunjLog(t *testing.T) {
+	t.Chdir(t.TempDir())
+	assert.NoError(t, os.WriteFile(runjLogFilename, []byte(`{"level":"error","msg":"earlier"}`+"\n"), 0o644))
+
+	l := newRunjLog()
+	if !assert.NotNil(t, l) {
+		return
+	}
+	entry := `{"level":"error","msg":"container not found"}` + "\n"
+	assert.NoError(t, os.WriteFile(l.path, []byte(entry), 0o644))
+	assert.EqualError(t, l.error(errors.New("exit status 1")), "container not found: exit status 1")
+
+	l.close()
+	assert.NoFileExists(t, l.path)
+	b, err := os.ReadFile(runjLogFilename)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"level":"error","msg":"earlier"}`+"\n"+entry, string(b))
+}
@@ -2,6 +2,7 @@ package containerd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -13,9 +14,14 @@ import (
 	"github.com/containerd/containerd/v2/pkg/namespaces"
 	"github.com/containerd/containerd/v2/pkg/shim"
 	"github.com/containerd/log"
+	"github.com/containerd/typeurl/v2"
 	"golang.org/x/sys/unix"
 )
 
+// FeaturesFreeBSDAnnotation is the RuntimeInfo annotation holding the FreeBSD
+// section of the runj features document
+const FeaturesFreeBSDAnnotation = "wtf.sbk.runj.features.freebsd"
+
 // NewManager returns a shim.Manager for the runj shim.  The manager forks the
 // shim process and handles fallback cleanup; the task API is served by the
 // plugin-registered service (see NewTaskService).
@@ -105,9 +111,28 @@ func (m manager) Stop(ctx context.Context, id string) (shim.StopStatus, error) {
 	}, nil
 }
 
-// Info returns runtime information for the shim.
+// Info returns runtime information for the shim, including the features of
+// the installed runj.  containerd only knows the features defined by the
+// runtime spec, so the FreeBSD section of the features document is returned as
+// JSON in the FeaturesFreeBSDAnnotation annotation.
 func (m manager) Info(ctx context.Context, optionsR io.Reader) (*types.RuntimeInfo, error) {
-	return &types.RuntimeInfo{Name: m.name}, nil
+	info := &types.RuntimeInfo{Name: m.name}
+	features, err := execFeatures(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get runj features: %w", err)
+	}
+	info.Features, err = typeurl.MarshalAnyToProto(&features.Features)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %T: %w", features.Features, err)
+	}
+	if features.FreeBSD != nil {
+		b, err := json.Marshal(features.FreeBSD)
+		if err != nil {
+			return nil, err
+		}
+		info.Annotations = map[string]string{FeaturesFreeBSDAnnotation: string(b)}
+	}
+	return info, nil
 }
 
 // newReexec creates a new exec.Cmd for running the shim API
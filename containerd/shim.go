@@ -19,6 +19,7 @@ import (
 	"github.com/containerd/console"
 	"github.com/containerd/containerd/api/events"
 	taskAPI "github.com/containerd/containerd/api/runtime/task/v3"
+	runcoptions "github.com/containerd/containerd/api/types/runc/options"
 	runtimeoptions "github.com/containerd/containerd/api/types/runtimeoptions/v1"
 	tasktypes "github.com/containerd/containerd/api/types/task"
 	cmount "github.com/containerd/containerd/v2/core/mount"
@@ -307,6 +308,11 @@ func (s *service) Create(ctx context.Context, req *taskAPI.CreateTaskRequest) (*
 		return nil, err
 	}
 
+	err = filterLinuxDefaults(req.Bundle)
+	if err != nil {
+		return nil, err
+	}
+
 	var mounts []cmount.Mount
 	for _, m := range req.Rootfs {
 		mounts = append(mounts, cmount.Mount{
@@ -520,6 +526,8 @@ func runjStatusToContainerdStatus(in string) tasktypes.Status {
 		return tasktypes.Status_CREATED
 	case state.StatusRunning:
 		return tasktypes.Status_RUNNING
+	case state.StatusPaused:
+		return tasktypes.Status_PAUSED
 	case state.StatusStopped:
 		return tasktypes.Status_STOPPED
 	}
@@ -603,19 +611,59 @@ func (s *service) startAux(ctx context.Context, id, execID string) (*taskAPI.Sta
 	}, nil
 }
 
+// Pids lists the processes running in the container.  Processes started
+// through Exec are identified by their exec ID.
 func (s *service) Pids(ctx context.Context, req *taskAPI.PidsRequest) (*taskAPI.PidsResponse, error) {
 	log.G(ctx).WithField("req", req).Warn("PIDS")
-	return nil, errdefs.ErrNotImplemented
+	if req.ID != s.id {
+		log.G(ctx).WithField("reqID", req.ID).WithField("id", s.id).Error("mismatched IDs")
+		return nil, errdefs.ErrInvalidArgument
+	}
+	processes, err := execPs(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &taskAPI.PidsResponse{}
+	for _, p := range processes {
+		info := &tasktypes.ProcessInfo{Pid: uint32(p.PID)}
+		if proc, execID := s.findProcess(p.PID); proc != nil && execID != "" {
+			info.Info, err = typeurl.MarshalAnyToProto(&runcoptions.ProcessDetails{ExecID: execID})
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal process %d info: %w", p.PID, err)
+			}
+		}
+		resp.Processes = append(resp.Processes, info)
+	}
+	return resp, nil
 }
 
+// Pause suspends all processes in a container by invoking "runj pause"
 func (s *service) Pause(ctx context.Context, req *taskAPI.PauseRequest) (*emptypb.Empty, error) {
 	log.G(ctx).WithField("req", req).Warn("PAUSE")
-	return nil, errdefs.ErrNotImplemented
+	if req.ID != s.id {
+		log.G(ctx).WithField("reqID", req.ID).WithField("id", s.id).Error("mismatched IDs")
+		return nil, errdefs.ErrInvalidArgument
+	}
+	if err := execPause(ctx, req.ID); err != nil {
+		return nil, err
+	}
+	s.sendL(&events.TaskPaused{ContainerID: s.id})
+	return empty, nil
 }
 
+// Resume continues all processes in a paused container by invoking
+// "runj resume"
 func (s *service) Resume(ctx context.Context, req *taskAPI.ResumeRequest) (*emptypb.Empty, error) {
 	log.G(ctx).WithField("req", req).Warn("RESUME")
-	return nil, errdefs.ErrNotImplemented
+	if req.ID != s.id {
+		log.G(ctx).WithField("reqID", req.ID).WithField("id", s.id).Error("mismatched IDs")
+		return nil, errdefs.ErrInvalidArgument
+	}
+	if err := execResume(ctx, req.ID); err != nil {
+		return nil, err
+	}
+	s.sendL(&events.TaskResumed{ContainerID: s.id})
+	return empty, nil
 }
 
 func (s *service) Checkpoint(ctx context.Context, req *taskAPI.CheckpointTaskRequest) (*emptypb.Empty, error) {
@@ -743,9 +791,43 @@ func (s *service) CloseIO(ctx context.Context, req *taskAPI.CloseIORequest) (*em
 	return nil, errdefs.ErrNotImplemented
 }
 
+// Update changes the resource limits of the container.  The Linux resources
+// in the request are translated by `runj update` into rctl(8) limits.
 func (s *service) Update(ctx context.Context, req *taskAPI.UpdateTaskRequest) (*emptypb.Empty, error) {
-	log.G(ctx).WithField("req", req).Warn("UPDATE")
-	return nil, errdefs.ErrNotImplemented
+	l := log.G(ctx).WithField("id", req.ID)
+	l.WithField("req", req).Warn("UPDATE")
+	if req.ID != s.id {
+		log.G(ctx).WithField("reqID", req.ID).WithField("id", s.id).Error("mismatched IDs")
+		return nil, errdefs.ErrInvalidArgument
+	}
+	resAny, err := typeurl.UnmarshalAny(req.Resources)
+	if err != nil {
+		l.WithError(err).Error("failed to unmarshal resources")
+		return nil, errdefs.ErrInvalidArgument
+	}
+	res, ok := resAny.(*specs.LinuxResources)
+	if !ok {
+		l.Error("mismatched type for resources")
+		return nil, errdefs.ErrInvalidArgument
+	}
+	// the device cgroup rules are part of containerd's Linux defaults, which
+	// are also removed from the bundle (see filterLinuxDefaults)
+	res.Devices = nil
+
+	f, err := os.CreateTemp("", "runj-resources")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	err = json.NewEncoder(f).Encode(res)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := execUpdate(ctx, req.ID, f.Name()); err != nil {
+		return nil, err
+	}
+	return empty, nil
 }
 
 // Wait blocks while the identified process is running and returns its exit code and exit timestamp when complete.
@@ -775,9 +857,23 @@ func (s *service) Wait(ctx context.Context, req *taskAPI.WaitRequest) (*taskAPI.
 	}, nil
 }
 
+// Stats returns the resource usage of the container as a *stats.Stats, which
+// is encoded as JSON under stats.TypeURL
 func (s *service) Stats(ctx context.Context, req *taskAPI.StatsRequest) (*taskAPI.StatsResponse, error) {
 	log.G(ctx).WithField("req", req).Warn("STATS")
-	return nil, errdefs.ErrNotImplemented
+	if req.ID != s.id {
+		log.G(ctx).WithField("reqID", req.ID).WithField("id", s.id).Error("mismatched IDs")
+		return nil, errdefs.ErrInvalidArgument
+	}
+	st, err := execStats(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := typeurl.MarshalAnyToProto(st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal stats: %w", err)
+	}
+	return &taskAPI.StatsResponse{Stats: data}, nil
 }
 
 func (s *service) Connect(ctx context.Context, req *taskAPI.ConnectRequest) (*taskAPI.ConnectResponse, error) {
@@ -0,0 +1,197 @@
+// Package user resolves OCI process users against passwd(5) and group(5)
+// databases.  The databases are read from files rather than through the C
+// library so that runj-entrypoint consults the jail's own /etc/passwd and
+// /etc/group after it has attached to the jail.
+package user
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Passwd is an entry in a passwd(5) file
+type Passwd struct {
+	Name string
+	UID  uint32
+	GID  uint32
+}
+
+// Group is an entry in a group(5) file
+type Group struct {
+	Name    string
+	GID     uint32
+	Members []string
+}
+
+// ParsePasswd parses passwd(5) entries.  Blank lines and comments are skipped.
+func ParsePasswd(r io.Reader) ([]Passwd, error) {
+	entries := make([]Passwd, 0)
+	err := parseLines(r, func(fields []string) error {
+		if len(fields) < 4 {
+			return fmt.Errorf("expected at least 4 fields, found %d", len(fields))
+		}
+		uid, err := parseID(fields[2])
+		if err != nil {
+			return err
+		}
+		gid, err := parseID(fields[3])
+		if err != nil {
+			return err
+		}
+		entries = append(entries, Passwd{Name: fields[0], UID: uid, GID: gid})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("passwd: %w", err)
+	}
+	return entries, nil
+}
+
+// ParseGroup parses group(5) entries.  Blank lines and comments are skipped.
+func ParseGroup(r io.Reader) ([]Group, error) {
+	entries := make([]Group, 0)
+	err := parseLines(r, func(fields []string) error {
+		if len(fields) < 3 {
+			return fmt.Errorf("expected at least 3 fields, found %d", len(fields))
+		}
+		gid, err := parseID(fields[2])
+		if err != nil {
+			return err
+		}
+		g := Group{Name: fields[0], GID: gid}
+		if len(fields) > 3 && fields[3] != "" {
+			g.Members = strings.Split(fields[3], ",")
+		}
+		entries = append(entries, g)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("group: %w", err)
+	}
+	return entries, nil
+}
+
+func parseLines(r io.Reader, fn func([]string) error) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		if err := fn(strings.Split(text, ":")); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func parseID(s string) (uint32, error) {
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", s)
+	}
+	return uint32(id), nil
+}
+
+// Resolve fills in the numeric fields of an OCI user from its Username, which
+// is either "user" or "user:group".  Each part may be a name or a numeric ID.
+// Numeric fields already set in u take precedence over the values found in the
+// databases: a non-zero UID or GID is kept, as is a non-empty AdditionalGids.
+// When AdditionalGids is empty, it is populated with the groups that list the
+// user as a member.  passwd and group may be nil when the jail has no such file.
+func Resolve(u runtimespec.User, passwd, group io.Reader) (runtimespec.User, error) {
+	if u.Username == "" {
+		return u, nil
+	}
+	userPart, groupPart, hasGroup := strings.Cut(u.Username, ":")
+	if userPart == "" || (hasGroup && groupPart == "") {
+		return u, fmt.Errorf("user: invalid username %q", u.Username)
+	}
+
+	var users []Passwd
+	if passwd != nil {
+		var err error
+		if users, err = ParsePasswd(passwd); err != nil {
+			return u, err
+		}
+	}
+	var groups []Group
+	if group != nil {
+		var err error
+		if groups, err = ParseGroup(group); err != nil {
+			return u, err
+		}
+	}
+
+	pw, err := findUser(users, userPart)
+	if err != nil {
+		return u, err
+	}
+	gid := pw.GID
+	if hasGroup {
+		g, err := findGroup(groups, groupPart)
+		if err != nil {
+			return u, err
+		}
+		gid = g.GID
+	}
+
+	if u.UID == 0 {
+		u.UID = pw.UID
+	}
+	if u.GID == 0 {
+		u.GID = gid
+	}
+	if len(u.AdditionalGids) == 0 && pw.Name != "" {
+		for _, g := range groups {
+			for _, member := range g.Members {
+				if member == pw.Name && g.GID != u.GID {
+					u.AdditionalGids = append(u.AdditionalGids, g.GID)
+					break
+				}
+			}
+		}
+	}
+	return u, nil
+}
+
+// findUser looks up a user by name, falling back to a numeric UID.  A numeric
+// UID that has no passwd entry is accepted with a GID of 0 and no name.
+func findUser(users []Passwd, name string) (Passwd, error) {
+	for _, pw := range users {
+		if pw.Name == name {
+			return pw, nil
+		}
+	}
+	uid, err := parseID(name)
+	if err != nil {
+		return Passwd{}, fmt.Errorf("user: unknown user %q", name)
+	}
+	for _, pw := range users {
+		if pw.UID == uid {
+			return pw, nil
+		}
+	}
+	return Passwd{UID: uid}, nil
+}
+
+// findGroup looks up a group by name, falling back to a numeric GID.
+func findGroup(groups []Group, name string) (Group, error) {
+	for _, g := range groups {
+		if g.Name == name {
+			return g, nil
+		}
+	}
+	gid, err := parseID(name)
+	if err != nil {
+		return Group{}, fmt.Errorf("user: unknown group %q", name)
+	}
+	return Group{GID: gid}, nil
+}
@@ -0,0 +1,126 @@
+package user
+
+import (
+	"strings"
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPasswd = `# $FreeBSD$
+#
+root:*:0:0:Charlie &:/root:/bin/sh
+www:*:80:80:World Wide Web Owner:/nonexistent:/usr/sbin/nologin
+nobody:*:65534:65534:Unprivileged user:/nonexistent:/usr/sbin/nologin
+app:*:1001:1001:Application:/home/app:/bin/sh
+`
+
+const testGroup = `# $FreeBSD$
+#
+wheel:*:0:root
+www:*:80:
+nogroup:*:65533:
+nobody:*:65534:
+app:*:1001:
+video:*:44:app,www
+staff:*:20:root,app
+`
+
+func TestParsePasswd(t *testing.T) {
+	entries, err := ParsePasswd(strings.NewReader(testPasswd))
+	require.NoError(t, err)
+	assert.Equal(t, []Passwd{
+		{Name: "root", UID: 0, GID: 0},
+		{Name: "www", UID: 80, GID: 80},
+		{Name: "nobody", UID: 65534, GID: 65534},
+		{Name: "app", UID: 1001, GID: 1001},
+	}, entries)
+}
+
+func TestParsePasswdInvalid(t *testing.T) {
+	_, err := ParsePasswd(strings.NewReader("root:*:zero:0::/root:/bin/sh\n"))
+	assert.EqualError(t, err, `passwd: line 1: invalid id "zero"`)
+
+	_, err = ParsePasswd(strings.NewReader("root:*\n"))
+	assert.EqualError(t, err, "passwd: line 1: expected at least 4 fields, found 2")
+}
+
+func TestParseGroup(t *testing.T) {
+	entries, err := ParseGroup(strings.NewReader(testGroup))
+	require.NoError(t, err)
+	require.Len(t, entries, 7)
+	assert.Equal(t, Group{Name: "wheel", GID: 0, Members: []string{"root"}}, entries[0])
+	assert.Equal(t, Group{Name: "www", GID: 80}, entries[1])
+	assert.Equal(t, Group{Name: "video", GID: 44, Members: []string{"app", "www"}}, entries[5])
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name string
+		in   runtimespec.User
+		out  runtimespec.User
+		err  string
+	}{{
+		name: "no-username",
+		in:   runtimespec.User{UID: 5, GID: 6},
+		out:  runtimespec.User{UID: 5, GID: 6},
+	}, {
+		name: "name",
+		in:   runtimespec.User{Username: "www"},
+		out:  runtimespec.User{Username: "www", UID: 80, GID: 80, AdditionalGids: []uint32{44}},
+	}, {
+		name: "name-and-group",
+		in:   runtimespec.User{Username: "nobody:nogroup"},
+		out:  runtimespec.User{Username: "nobody:nogroup", UID: 65534, GID: 65533},
+	}, {
+		name: "supplementary-groups",
+		in:   runtimespec.User{Username: "app"},
+		out:  runtimespec.User{Username: "app", UID: 1001, GID: 1001, AdditionalGids: []uint32{44, 20}},
+	}, {
+		name: "numeric-user",
+		in:   runtimespec.User{Username: "1001"},
+		out:  runtimespec.User{Username: "1001", UID: 1001, GID: 1001, AdditionalGids: []uint32{44, 20}},
+	}, {
+		name: "numeric-user-without-entry",
+		in:   runtimespec.User{Username: "2000:3000"},
+		out:  runtimespec.User{Username: "2000:3000", UID: 2000, GID: 3000},
+	}, {
+		name: "numeric-fields-take-precedence",
+		in:   runtimespec.User{Username: "www", UID: 1500, GID: 1600, AdditionalGids: []uint32{7}},
+		out:  runtimespec.User{Username: "www", UID: 1500, GID: 1600, AdditionalGids: []uint32{7}},
+	}, {
+		name: "unknown-user",
+		in:   runtimespec.User{Username: "postgres"},
+		err:  `user: unknown user "postgres"`,
+	}, {
+		name: "unknown-group",
+		in:   runtimespec.User{Username: "www:postgres"},
+		err:  `user: unknown group "postgres"`,
+	}, {
+		name: "empty-group",
+		in:   runtimespec.User{Username: "www:"},
+		err:  `user: invalid username "www:"`,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := Resolve(tc.in, strings.NewReader(testPasswd), strings.NewReader(testGroup))
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.out, actual)
+		})
+	}
+}
+
+func TestResolveMissingDatabases(t *testing.T) {
+	_, err := Resolve(runtimespec.User{Username: "www"}, nil, nil)
+	assert.EqualError(t, err, `user: unknown user "www"`)
+
+	u, err := Resolve(runtimespec.User{Username: "80:80"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, runtimespec.User{Username: "80:80", UID: 80, GID: 80}, u)
+}
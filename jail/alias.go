@@ -0,0 +1,101 @@
+package jail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"os/exec"
+	"path/filepath"
+	"slices"
+)
+
+// AddAliases adds each address that is not already configured on the host
+// interface iface as an alias with ifconfig(8), like the interface parameter of
+// jail(8).  The added addresses are returned so that exactly those can be
+// removed with RemoveAliases.  If an alias cannot be added, the aliases added so
+// far are removed.
+func AddAliases(ctx context.Context, iface string, addrs []string) ([]string, error) {
+	existing, err := interfaceAddrs(iface)
+	if err != nil {
+		return nil, err
+	}
+	added := make([]string, 0, len(addrs))
+	for _, a := range addrs {
+		addr, err := netip.ParseAddr(a)
+		if err != nil {
+			RemoveAliases(ctx, iface, added)
+			return nil, fmt.Errorf("alias: %w", err)
+		}
+		if slices.Contains(existing, addr) {
+			continue
+		}
+		if err := ifconfigAlias(ctx, aliasArgs(iface, addr, false)); err != nil {
+			RemoveAliases(ctx, iface, added)
+			return nil, err
+		}
+		existing = append(existing, addr)
+		added = append(added, addr.String())
+	}
+	return added, nil
+}
+
+// RemoveAliases removes aliases added with AddAliases from the host interface
+// iface.  Every alias is attempted; the first error is returned.
+func RemoveAliases(ctx context.Context, iface string, addrs []string) error {
+	var firstErr error
+	for _, a := range addrs {
+		addr, err := netip.ParseAddr(a)
+		if err == nil {
+			err = ifconfigAlias(ctx, aliasArgs(iface, addr, true))
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// aliasArgs returns the ifconfig(8) arguments that add or remove addr as an
+// alias on iface.  Aliases use a host prefix so that they do not change the
+// interface's routes.
+func aliasArgs(iface string, addr netip.Addr, remove bool) []string {
+	family, prefix := "inet", "/32"
+	if addr.Is6() {
+		family, prefix = "inet6", "/128"
+	}
+	if remove {
+		return []string{iface, family, addr.String(), "-alias"}
+	}
+	return []string{iface, family, addr.String() + prefix, "alias"}
+}
+
+func ifconfigAlias(ctx context.Context, args []string) error {
+	cmd := exec.CommandContext(ctx, filepath.Clean(ifconfig), args...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ifconfig: %q: %w", out, err)
+	}
+	return nil
+}
+
+// interfaceAddrs returns the addresses configured on the host interface iface
+func interfaceAddrs(iface string) ([]netip.Addr, error) {
+	i, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("alias: %w", err)
+	}
+	addrs, err := i.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("alias: %s: %w", iface, err)
+	}
+	var result []netip.Addr
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok {
+			if addr, ok := netip.AddrFromSlice(ipnet.IP); ok {
+				result = append(result, addr.Unmap())
+			}
+		}
+	}
+	return result, nil
+}
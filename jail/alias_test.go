@@ -0,0 +1,40 @@
+package jail
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		addr   string
+		remove bool
+		args   []string
+	}{{
+		name: "ipv4",
+		addr: "192.0.2.10",
+		args: []string{"em0", "inet", "192.0.2.10/32", "alias"},
+	}, {
+		name:   "ipv4 remove",
+		addr:   "192.0.2.10",
+		remove: true,
+		args:   []string{"em0", "inet", "192.0.2.10", "-alias"},
+	}, {
+		name: "ipv6",
+		addr: "2001:db8::10",
+		args: []string{"em0", "inet6", "2001:db8::10/128", "alias"},
+	}, {
+		name:   "ipv6 remove",
+		addr:   "2001:db8::10",
+		remove: true,
+		args:   []string{"em0", "inet6", "2001:db8::10", "-alias"},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.args, aliasArgs("em0", netip.MustParseAddr(tc.addr), tc.remove))
+		})
+	}
+}
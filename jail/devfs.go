@@ -0,0 +1,162 @@
+package jail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	devfsCmd = "/sbin/devfs"
+
+	// devfsRulesetBase is the lowest ruleset number allocated for containers,
+	// leaving lower numbers to devfs.rules(5)
+	devfsRulesetBase = 1000
+	// devfsRulesetMax is the highest ruleset number devfs(8) accepts
+	devfsRulesetMax = 65535
+
+	// devfsLockFile serializes ruleset allocation between concurrent
+	// invocations of runj
+	devfsLockFile = "/var/run/runj.devfs.lock"
+)
+
+// devfsBasicDevices are the devices made available by every container ruleset
+// in addition to the configured devices.  They match the devices unhidden by
+// the devfsrules_unhide_basic ruleset in /etc/defaults/devfs.rules.
+var devfsBasicDevices = []string{
+	"crypto", "ctty", "fd", "fd/*", "full", "null", "pts", "pts/*", "ptmx",
+	"random", "stderr", "stdin", "stdout", "urandom", "zero",
+}
+
+// ValidateDevices checks that each device path is a relative path below /dev
+func ValidateDevices(devices []runtimespec.FreeBSDDevice) error {
+	for _, d := range devices {
+		if d.Path == "" || path.IsAbs(d.Path) || path.Clean(d.Path) != d.Path || strings.HasPrefix(d.Path, "..") {
+			return fmt.Errorf("devfs: invalid device path %q: must be relative to /dev", d.Path)
+		}
+	}
+	return nil
+}
+
+// devfsRules returns the arguments to `devfs rule -s <ruleset>` for each rule
+// of a ruleset that hides every device except the basic devices and the
+// configured devices
+func devfsRules(devices []runtimespec.FreeBSDDevice) [][]string {
+	rules := [][]string{{"add", "hide"}}
+	for _, d := range devfsBasicDevices {
+		rules = append(rules, []string{"add", "path", d, "unhide"})
+	}
+	for _, d := range devices {
+		rule := []string{"add", "path", d.Path, "unhide"}
+		if d.Mode != nil {
+			rule = append(rule, "mode", fmt.Sprintf("%#o", d.Mode.Perm()))
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// CreateDevfsRuleset creates a devfs ruleset exposing the configured devices
+// and returns its number.  The ruleset should be removed with
+// DeleteDevfsRuleset.
+func CreateDevfsRuleset(ctx context.Context, devices []runtimespec.FreeBSDDevice) (int, error) {
+	lock, err := os.OpenFile(devfsLockFile, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("devfs: %w", err)
+	}
+	defer lock.Close()
+	if err := unix.Flock(int(lock.Fd()), unix.LOCK_EX); err != nil {
+		return 0, fmt.Errorf("devfs: lock: %w", err)
+	}
+	defer unix.Flock(int(lock.Fd()), unix.LOCK_UN)
+
+	ruleset, err := freeDevfsRuleset(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, rule := range devfsRules(devices) {
+		if err := devfsRule(ctx, ruleset, rule...); err != nil {
+			DeleteDevfsRuleset(ctx, ruleset)
+			return 0, err
+		}
+	}
+	return ruleset, nil
+}
+
+// DeleteDevfsRuleset deletes a ruleset created with CreateDevfsRuleset
+func DeleteDevfsRuleset(ctx context.Context, ruleset int) error {
+	return devfsRule(ctx, ruleset, "delset")
+}
+
+// freeDevfsRuleset returns the lowest ruleset number, starting at
+// devfsRulesetBase, that is not in use
+func freeDevfsRuleset(ctx context.Context) (int, error) {
+	out, err := exec.CommandContext(ctx, devfsCmd, "rule", "showsets").Output()
+	if err != nil {
+		return 0, fmt.Errorf("devfs: showsets: %w", err)
+	}
+	used, err := parseShowsets(out)
+	if err != nil {
+		return 0, err
+	}
+	for ruleset := devfsRulesetBase; ruleset <= devfsRulesetMax; ruleset++ {
+		if !used[ruleset] {
+			return ruleset, nil
+		}
+	}
+	return 0, fmt.Errorf("devfs: no free ruleset above %d", devfsRulesetBase)
+}
+
+// parseShowsets parses the output of `devfs rule showsets`, which lists one
+// ruleset number per line
+func parseShowsets(out []byte) (map[int]bool, error) {
+	used := make(map[int]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return nil, fmt.Errorf("devfs: showsets: unexpected output %q", line)
+		}
+		used[n] = true
+	}
+	return used, scanner.Err()
+}
+
+func devfsRule(ctx context.Context, ruleset int, args ...string) error {
+	args = append([]string{"rule", "-s", strconv.Itoa(ruleset)}, args...)
+	out, err := exec.CommandContext(ctx, devfsCmd, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("devfs: %s: %q: %w", strings.Join(args, " "), out, err)
+	}
+	return nil
+}
+
+// ApplyDevfsRuleset sets the ruleset option of the devfs mounts in the spec,
+// replacing any ruleset they specify
+func ApplyDevfsRuleset(ociConfig *runtimespec.Spec, ruleset int) {
+	for i, m := range ociConfig.Mounts {
+		if m.Type != "devfs" {
+			continue
+		}
+		options := make([]string, 0, len(m.Options)+1)
+		for _, opt := range m.Options {
+			if !strings.HasPrefix(opt, "ruleset=") {
+				options = append(options, opt)
+			}
+		}
+		ociConfig.Mounts[i].Options = append(options, "ruleset="+strconv.Itoa(ruleset))
+	}
+}
@@ -0,0 +1,72 @@
+package jail
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDevices(t *testing.T) {
+	tests := []struct {
+		path string
+		err  error
+	}{
+		{path: "tun0"},
+		{path: "bpf*"},
+		{path: "pts/0"},
+		{path: "", err: errors.New(`devfs: invalid device path "": must be relative to /dev`)},
+		{path: "/dev/tun0", err: errors.New(`devfs: invalid device path "/dev/tun0": must be relative to /dev`)},
+		{path: "../etc", err: errors.New(`devfs: invalid device path "../etc": must be relative to /dev`)},
+		{path: "pts/../tun0", err: errors.New(`devfs: invalid device path "pts/../tun0": must be relative to /dev`)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			err := ValidateDevices([]runtimespec.FreeBSDDevice{{Path: tc.path}})
+			if tc.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.err.Error())
+		})
+	}
+}
+
+func TestDevfsRules(t *testing.T) {
+	mode := os.FileMode(0660)
+	rules := devfsRules([]runtimespec.FreeBSDDevice{
+		{Path: "tun0"},
+		{Path: "bpf", Mode: &mode},
+	})
+	require.Len(t, rules, 1+len(devfsBasicDevices)+2)
+	assert.Equal(t, []string{"add", "hide"}, rules[0], "the ruleset should hide everything first")
+	assert.Equal(t, []string{"add", "path", "null", "unhide"}, rules[1+5])
+	assert.Equal(t, []string{"add", "path", "tun0", "unhide"}, rules[len(rules)-2])
+	assert.Equal(t, []string{"add", "path", "bpf", "unhide", "mode", "0660"}, rules[len(rules)-1])
+}
+
+func TestParseShowsets(t *testing.T) {
+	used, err := parseShowsets([]byte("1\n2\n3\n4\n1000\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true, 4: true, 1000: true}, used)
+
+	_, err = parseShowsets([]byte("one\n"))
+	assert.EqualError(t, err, `devfs: showsets: unexpected output "one"`)
+}
+
+func TestApplyDevfsRuleset(t *testing.T) {
+	spec := &runtimespec.Spec{
+		Mounts: []runtimespec.Mount{
+			{Destination: "/dev", Type: "devfs", Source: "devfs", Options: []string{"ruleset=4"}},
+			{Destination: "/tmp", Type: "tmpfs", Source: "tmpfs", Options: []string{"ruleset=4"}},
+			{Destination: "/other/dev", Type: "devfs", Source: "devfs"},
+		},
+	}
+	ApplyDevfsRuleset(spec, 1000)
+	assert.Equal(t, []string{"ruleset=1000"}, spec.Mounts[0].Options)
+	assert.Equal(t, []string{"ruleset=4"}, spec.Mounts[1].Options, "non-devfs mounts are left alone")
+	assert.Equal(t, []string{"ruleset=1000"}, spec.Mounts[2].Options)
+}
@@ -2,6 +2,7 @@ package jail
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"strconv"
 	"syscall"
 
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/sys/unix"
 
 	"go.sbk.wtf/runj/state"
@@ -21,11 +23,108 @@ const (
 	execFifoFilename = "exec.fifo"
 	execSkipFifo     = "-"
 	consoleSocketEnv = "__RUNJ_CONSOLE_SOCKET"
+	processEnv       = "__RUNJ_PROCESS"
+	hooksEnv         = "__RUNJ_HOOKS"
+	syncEnv          = "__RUNJ_SYNC"
 	stdioFdCount     = 3
 )
 
+// EntrypointProcess is the subset of the OCI process configuration that
+// runj-entrypoint applies inside the jail before it exec(2)s the target
+// program.  process.args and process.env are not included; they are passed to
+// runj-entrypoint as its arguments and environment.
+//
+// Note: this API is unstable; expect it to change.
+type EntrypointProcess struct {
+	// User is the user and groups the process runs as
+	User runtimespec.User `json:"user"`
+	// Cwd is the working directory of the process, relative to the jail's
+	// root
+	Cwd string `json:"cwd,omitempty"`
+	// Rlimits are the resource limits applied with setrlimit(2)
+	Rlimits []runtimespec.POSIXRlimit `json:"rlimits,omitempty"`
+	// ConsoleSize is the initial size of the pty allocated when the process
+	// has a terminal
+	ConsoleSize *runtimespec.Box `json:"consoleSize,omitempty"`
+}
+
+// EntrypointHooks are the hooks that runj-entrypoint runs inside the jail for
+// the jail's init process, along with the state passed to them on STDIN.
+// runj-entrypoint fills in the PID and the status of the state.
+//
+// Note: this API is unstable; expect it to change.
+type EntrypointHooks struct {
+	// State is the state of the container
+	State state.Output `json:"state"`
+	// CreateContainer hooks are run in the jail as part of `runj create`.
+	// Their paths resolve on the host; runj-entrypoint opens each program
+	// before attaching and executes it with fexecve(2).
+	CreateContainer []runtimespec.Hook `json:"createContainer,omitempty"`
+	// StartContainer hooks are run after attaching to the jail, as part of
+	// `runj start` and before the target program is started
+	StartContainer []runtimespec.Hook `json:"startContainer,omitempty"`
+}
+
+// Entrypoint is a runj-entrypoint process started by SetupEntrypoint
+type Entrypoint struct {
+	*exec.Cmd
+	// sync is runj's end of the socket used to coordinate with
+	// runj-entrypoint during `runj create`
+	sync *os.File
+}
+
+// Create signals runj-entrypoint that the runtime environment is ready (the
+// createRuntime hooks have run), then waits for runj-entrypoint to run the
+// createContainer hooks and attach to the jail.  An error is returned if
+// runj-entrypoint fails before the container is created.
+func (e *Entrypoint) Create() error {
+	if e.sync == nil {
+		return nil
+	}
+	defer e.sync.Close()
+	if _, err := e.sync.Write([]byte("0")); err != nil {
+		return fmt.Errorf("entrypoint: %w", err)
+	}
+	return readFromSync(e.sync)
+}
+
+// readFromSync reads the result runj-entrypoint reports when the container is
+// created: a single "0" byte on success, an error message on failure, or
+// nothing if it exited.
+func readFromSync(sync io.Reader) error {
+	data, err := io.ReadAll(sync)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return errors.New("entrypoint: exited before the container was created")
+	}
+	if string(data) != "0" {
+		return fmt.Errorf("entrypoint: %s", data)
+	}
+	return nil
+}
+
+// entrypointEnv returns the environment for a runj-entrypoint process: the
+// process environment plus the encoded EntrypointProcess.
+func entrypointEnv(process *runtimespec.Process) ([]string, error) {
+	ep := EntrypointProcess{
+		User:        process.User,
+		Cwd:         process.Cwd,
+		Rlimits:     process.Rlimits,
+		ConsoleSize: process.ConsoleSize,
+	}
+	b, err := json.Marshal(ep)
+	if err != nil {
+		return nil, err
+	}
+	env := make([]string, 0, len(process.Env)+1)
+	env = append(env, process.Env...)
+	return append(env, processEnv+"="+string(b)), nil
+}
+
 // SetupEntrypoint starts a runj-entrypoint process, which is used to start
-// processes inside the jail.
+// processes inside the jail named jailName for the container id.
 //
 // When used to start the jail's init process, runj-entrypoint will later be
 // signalled through `runj start` to run the specified program in the jail. This
@@ -37,21 +136,33 @@ const (
 // as soon as STDIO is configured.
 //
 // Note: this API is unstable; expect it to change.
-func SetupEntrypoint(id string, init bool, argv []string, env []string, consoleSocketPath string) (*exec.Cmd, error) {
+func SetupEntrypoint(id string, jailName string, init bool, process *runtimespec.Process, hooks *EntrypointHooks, consoleSocketPath string) (*Entrypoint, error) {
+	env, err := entrypointEnv(process)
+	if err != nil {
+		return nil, err
+	}
 	path := execSkipFifo
 	if init {
-		var err error
 		path, err = createExecFifo(id)
 		if err != nil {
 			return nil, err
 		}
 	}
-	args := append([]string{id, path}, argv...)
+	args := append([]string{jailName, path}, process.Args...)
 	cmd := exec.Command("runj-entrypoint", args...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Env = env
+	e := &Entrypoint{Cmd: cmd}
+
+	if hooks != nil {
+		b, err := json.Marshal(hooks)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Env = append(cmd.Env, hooksEnv+"="+string(b))
+	}
 
 	// the caller of runj will handle receiving the console master
 	if consoleSocketPath != "" {
@@ -73,14 +184,39 @@ func SetupEntrypoint(id string, init bool, argv []string, env []string, consoleS
 		)
 	}
 
-	return cmd, cmd.Start()
+	// runj-entrypoint reports the outcome of `runj create` over a socket
+	if init {
+		fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+		if err != nil {
+			return nil, fmt.Errorf("entrypoint: sync socket: %w", err)
+		}
+		e.sync = os.NewFile(uintptr(fds[0]), "sync")
+		entrypointSync := os.NewFile(uintptr(fds[1]), "sync")
+		defer entrypointSync.Close()
+		cmd.ExtraFiles = append(cmd.ExtraFiles, entrypointSync)
+		cmd.Env = append(cmd.Env,
+			syncEnv+"="+strconv.Itoa(stdioFdCount+len(cmd.ExtraFiles)-1),
+		)
+	}
+
+	if err := cmd.Start(); err != nil {
+		if e.sync != nil {
+			e.sync.Close()
+		}
+		return nil, err
+	}
+	return e, nil
 }
 
 // ExecEntrypoint execs a runj-entrypoint process in order to start processes
-// inside the jail.
+// inside the jail named jailName.
 //
 // Note: this API is unstable; expect it to change.
-func ExecEntrypoint(id string, argv []string, env []string, consoleSocketPath string) error {
+func ExecEntrypoint(jailName string, process *runtimespec.Process, consoleSocketPath string) error {
+	env, err := entrypointEnv(process)
+	if err != nil {
+		return err
+	}
 	// the caller of runj will handle receiving the console master
 	if consoleSocketPath != "" {
 		conn, err := net.Dial("unix", consoleSocketPath)
@@ -105,7 +241,7 @@ func ExecEntrypoint(id string, argv []string, env []string, consoleSocketPath st
 	if err != nil {
 		return err
 	}
-	args := append([]string{"runj-entrypoint", id, execSkipFifo}, argv...)
+	args := append([]string{"runj-entrypoint", jailName, execSkipFifo}, process.Args...)
 	return unix.Exec(path, args, env)
 }
 
@@ -196,6 +332,11 @@ func readFromExecFifo(execFifo io.Reader) error {
 	if len(data) <= 0 {
 		return errors.New("cannot start an already running container")
 	}
+	// runj-entrypoint writes a single byte when it is unblocked, followed by
+	// an error message if it fails before starting the container process.
+	if len(data) > 1 {
+		return fmt.Errorf("entrypoint: %s", data[1:])
+	}
 	return nil
 }
 
@@ -0,0 +1,66 @@
+package jail
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFromExecFifo(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{{
+		name: "started",
+		data: "0",
+	}, {
+		name: "already-started",
+		data: "",
+		err:  "cannot start an already running container",
+	}, {
+		name: "entrypoint-error",
+		data: `0user: unknown user "www"`,
+		err:  `entrypoint: user: unknown user "www"`,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := readFromExecFifo(strings.NewReader(tc.data))
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestReadFromSync(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{{
+		name: "created",
+		data: "0",
+	}, {
+		name: "exited",
+		data: "",
+		err:  "entrypoint: exited before the container was created",
+	}, {
+		name: "hook-error",
+		data: `createContainer hook "/hook": exit status 1`,
+		err:  `entrypoint: createContainer hook "/hook": exit status 1`,
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := readFromSync(strings.NewReader(tc.data))
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -3,7 +3,10 @@ package jail
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
+
+	"go.sbk.wtf/runj/state"
 )
 
 // Jail represents an existing jail
@@ -12,7 +15,8 @@ type Jail interface {
 	Attach() error
 	// Remove destroys the jail
 	Remove() error
-	id() ID
+	// ID returns the jail ID (JID) assigned by the kernel
+	ID() ID
 }
 
 type jail struct {
@@ -33,7 +37,124 @@ func Create(config *CreateParams) (Jail, error) {
 	return &jail{_id: jid}, nil
 }
 
-// FromName queries the OS for a jail with the specified name
+// Name returns the name of the jail backing a container: the container ID for a
+// top-level jail, or "parent.id" for a child of the named parent jail
+func Name(parent, id string) string {
+	if parent == "" {
+		return id
+	}
+	return parent + "." + id
+}
+
+// ancestors returns the names of the jails above a jail named with Name,
+// outermost first.  For "a.b.c" these are "a" and "a.b".
+func ancestors(name string) []string {
+	var names []string
+	for i, r := range name {
+		if r == '.' {
+			names = append(names, name[:i])
+		}
+	}
+	return names
+}
+
+// AllowChildren raises the children.max parameter of the named jail and of the
+// jails above it, where needed, so that another child jail can be created in
+// it.  The kernel counts every descendant of a jail against its children.max.
+// The changes are returned so that RestoreChildren can undo them; on error,
+// the changes already made are undone.
+func AllowChildren(parent string) ([]state.ChildrenMax, error) {
+	var changes []state.ChildrenMax
+	for _, name := range append(ancestors(parent), parent) {
+		j, err := FromName(name)
+		if err != nil {
+			RestoreChildren(changes)
+			return nil, fmt.Errorf("parent jail %q: %w", name, err)
+		}
+		children, err := getInt32(j.ID(), "children.cur", "children.max")
+		if err != nil {
+			RestoreChildren(changes)
+			return nil, fmt.Errorf("parent jail %q: children: %w", name, err)
+		}
+		if children[1] > children[0] {
+			continue
+		}
+		if err := setInt32(j.ID(), "children.max", children[0]+1); err != nil {
+			RestoreChildren(changes)
+			return nil, fmt.Errorf("parent jail %q: children.max: %w", name, err)
+		}
+		changes = append(changes, state.ChildrenMax{
+			Jail:     name,
+			Original: int(children[1]),
+			Raised:   int(children[0]) + 1,
+		})
+	}
+	return changes, nil
+}
+
+// RestoreChildren undoes the changes made by AllowChildren, once the child
+// jail has been removed.  Each children.max is lowered by the amount it was
+// raised, so that the changes made for other containers in the meantime are
+// kept, but never below the number of jails still in the jail.  Jails that no
+// longer exist are skipped.
+func RestoreChildren(changes []state.ChildrenMax) error {
+	var errs []error
+	for _, c := range slices.Backward(changes) {
+		j, err := FromName(c.Jail)
+		if err != nil {
+			continue
+		}
+		children, err := getInt32(j.ID(), "children.cur", "children.max")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parent jail %q: children: %w", c.Jail, err))
+			continue
+		}
+		restored := max(children[1]-int32(c.Raised-c.Original), children[0])
+		if restored == children[1] {
+			continue
+		}
+		if err := setInt32(j.ID(), "children.max", restored); err != nil {
+			errs = append(errs, fmt.Errorf("parent jail %q: children.max: %w", c.Jail, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ChildCount returns the number of jails below the named jail
+func ChildCount(name string) (int, error) {
+	j, err := FromName(name)
+	if err != nil {
+		return 0, err
+	}
+	children, err := getInt32(j.ID(), "children.cur")
+	if err != nil {
+		return 0, err
+	}
+	return int(children[0]), nil
+}
+
+// Update changes the parameters of the named jail
+func Update(name string, params *UpdateParams) error {
+	j, err := FromName(name)
+	if err != nil {
+		return err
+	}
+	current, err := getInt32(j.ID(), "enforce_statfs")
+	if err != nil {
+		return fmt.Errorf("jail: enforce_statfs: %w", err)
+	}
+	iovec, err := params.iovec(name, int(current[0]))
+	if err != nil {
+		return err
+	}
+	if _, err := set(iovec, _FLAG_UPDATE); err != nil {
+		return fmt.Errorf("failed to invoke jail_set: %w", err)
+	}
+	return nil
+}
+
+// FromName queries the OS for a jail with the specified name.  Child jails are
+// named by their full hierarchical name (see Name).
 func FromName(name string) (Jail, error) {
 	id, err := find(name)
 	if err != nil {
@@ -61,6 +182,6 @@ func (j *jail) Remove() error {
 	return nil
 }
 
-func (j *jail) id() ID {
+func (j *jail) ID() ID {
 	return j._id
 }
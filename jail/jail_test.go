@@ -0,0 +1,29 @@
+package jail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "container", Name("", "container"))
+	assert.Equal(t, "parent.container", Name("parent", "container"))
+	assert.Equal(t, "a.b.container", Name("a.b", "container"))
+}
+
+func TestAncestors(t *testing.T) {
+	tests := []struct {
+		name      string
+		ancestors []string
+	}{
+		{name: "a"},
+		{name: "a.b", ancestors: []string{"a"}},
+		{name: "a.b.c", ancestors: []string{"a", "a.b"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.ancestors, ancestors(tc.name))
+		})
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"os/exec"
 	"strconv"
+	"strings"
+	"time"
 
 	"golang.org/x/sys/unix"
 )
@@ -22,3 +24,40 @@ func Kill(ctx context.Context, jail string, pid int, signal unix.Signal) error {
 func KillAll(ctx context.Context, jail string, signal unix.Signal) error {
 	return Kill(ctx, jail, -1, signal)
 }
+
+const (
+	// stopAttempts bounds how many times StopAll signals the jail
+	stopAttempts = 10
+	// stopInterval is how long StopAll waits for the signal to be delivered
+	// before checking the processes
+	stopInterval = 50 * time.Millisecond
+)
+
+// StopAll sends SIGSTOP to all processes in a jail until ps(1) reports every
+// process as stopped.  A process forked while the signal is being delivered
+// escapes it, so the signal is repeated.  An error is returned if processes
+// are still running after stopAttempts signals.
+func StopAll(ctx context.Context, jail string) error {
+	var running []int
+	for range stopAttempts {
+		if err := KillAll(ctx, jail, unix.SIGSTOP); err != nil {
+			return err
+		}
+		time.Sleep(stopInterval)
+		procs, err := Processes(ctx, jail)
+		if err != nil {
+			return err
+		}
+		running = running[:0]
+		for _, p := range procs {
+			// zombies cannot be stopped, but no longer run either
+			if !strings.HasPrefix(p.State, "T") && !strings.HasPrefix(p.State, "Z") {
+				running = append(running, p.PID)
+			}
+		}
+		if len(running) == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("jail %q: processes %v did not stop", jail, running)
+}
@@ -7,8 +7,71 @@ import (
 	"github.com/containerd/containerd/v2/core/mount"
 
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+
+	"go.sbk.wtf/runj/state"
 )
 
+// readonlyRootDirname is the directory within the container's state directory
+// where the read-only view of the root filesystem is mounted
+const readonlyRootDirname = "root"
+
+// ReadonlyRootPath returns the path at which MountRoot mounts the read-only
+// view of a container's root filesystem
+func ReadonlyRootPath(id string) string {
+	return filepath.Join(state.Dir(id), readonlyRootDirname)
+}
+
+// MountRoot implements root.readonly.  When it is set, the root filesystem is
+// mounted read-only with nullfs at ReadonlyRootPath and ociConfig.Root.Path is
+// updated to refer to that view, so that the jail and the mounts applied by
+// Mount use it.  The view must be mounted before the jail is created, as the
+// jail's root is resolved when the jail is created.
+//
+// Mount points for nullfs mounts are created first, since they cannot be
+// created once the root is read-only.
+func MountRoot(id string, ociConfig *runtimespec.Spec) error {
+	if ociConfig.Root == nil || !ociConfig.Root.Readonly {
+		return nil
+	}
+	for _, ociMount := range ociConfig.Mounts {
+		if ociMount.Type != "nullfs" {
+			continue
+		}
+		stat, err := os.Stat(ociMount.Source)
+		if err != nil {
+			return err
+		}
+		err = createIfNotExists(filepath.Join(ociConfig.Root.Path, ociMount.Destination), stat.IsDir())
+		if err != nil {
+			return err
+		}
+	}
+	view := ReadonlyRootPath(id)
+	if err := os.Mkdir(view, 0755); err != nil && !os.IsExist(err) {
+		return err
+	}
+	m := &mount.Mount{
+		Type:    "nullfs",
+		Source:  ociConfig.Root.Path,
+		Options: []string{"ro"},
+	}
+	if err := m.Mount(view); err != nil {
+		return err
+	}
+	ociConfig.Root.Path = view
+	return nil
+}
+
+// UnmountRoot unmounts the read-only view of the root filesystem mounted by
+// MountRoot.  It must be called after Unmount, as the mounts are layered on top
+// of the view.
+func UnmountRoot(id string, ociConfig *runtimespec.Spec) error {
+	if ociConfig.Root == nil || !ociConfig.Root.Readonly {
+		return nil
+	}
+	return mount.Unmount(ReadonlyRootPath(id), 0)
+}
+
 // Mount mounts the mounts
 func Mount(ociConfig *runtimespec.Spec) error {
 	var err error
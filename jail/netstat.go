@@ -0,0 +1,96 @@
+package jail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"go.sbk.wtf/runj/stats"
+)
+
+const netstat = "/usr/bin/netstat"
+
+// InterfaceStats returns the counters of the network interfaces in the vnet of
+// a jail.  It depends on the host's "netstat" command, which attaches to the
+// jail.
+func InterfaceStats(ctx context.Context, jail string) ([]stats.NetworkInterface, error) {
+	cmd := exec.CommandContext(ctx, netstat, "-j", jail, "-i", "-b", "-n", "--libxo", "json")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("netstat: %q: %w", stderr.String(), err)
+	}
+	return parseNetstat(out)
+}
+
+// parseNetstat parses the libxo json output of `netstat -i -b`.  netstat lists
+// each interface once for its link and once for each address; only the link
+// entries are used, as they hold the counters of the whole interface.
+func parseNetstat(out []byte) ([]stats.NetworkInterface, error) {
+	result := &netstatOutput{}
+	if err := json.Unmarshal(out, result); err != nil {
+		return nil, err
+	}
+	if result.Statistics == nil {
+		return nil, errors.New("netstat: nil result")
+	}
+	var ifaces []stats.NetworkInterface
+	for _, i := range result.Statistics.Interfaces {
+		if !strings.HasPrefix(i.Network, "<Link#") {
+			continue
+		}
+		ifaces = append(ifaces, stats.NetworkInterface{
+			Name:      i.Name,
+			RxBytes:   uint64(i.ReceivedBytes),
+			RxPackets: uint64(i.ReceivedPackets),
+			RxErrors:  uint64(i.ReceivedErrors),
+			TxBytes:   uint64(i.SentBytes),
+			TxPackets: uint64(i.SentPackets),
+			TxErrors:  uint64(i.SendErrors),
+		})
+	}
+	return ifaces, nil
+}
+
+type netstatOutput struct {
+	Statistics *netstatStatistics `json:"statistics"`
+}
+
+type netstatStatistics struct {
+	Interfaces []netstatInterface `json:"interface"`
+}
+
+type netstatInterface struct {
+	Name            string    `json:"name"`
+	Network         string    `json:"network"`
+	ReceivedPackets xoCounter `json:"received-packets"`
+	ReceivedErrors  xoCounter `json:"received-errors"`
+	ReceivedBytes   xoCounter `json:"received-bytes"`
+	SentPackets     xoCounter `json:"sent-packets"`
+	SendErrors      xoCounter `json:"send-errors"`
+	SentBytes       xoCounter `json:"sent-bytes"`
+}
+
+// xoCounter is a counter in libxo json output, which may be encoded either as
+// a number or as a string
+type xoCounter uint64
+
+func (c *xoCounter) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(strings.Trim(string(data), `"`))
+	if s == "" || s == "-" {
+		*c = 0
+		return nil
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("netstat: invalid counter %s: %w", data, err)
+	}
+	*c = xoCounter(n)
+	return nil
+}
@@ -0,0 +1,44 @@
+package jail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.sbk.wtf/runj/stats"
+)
+
+// netstatSample is the output of `netstat -i -b -n --libxo json` in a vnet jail
+const netstatSample = `{"statistics": {"interface": [` +
+	`{"name":"lo0","flags":"0x8049","mtu":16384,"network":"<Link#1>","address":"lo0","received-packets":4,"received-errors":0,"dropped-packets":0,"received-bytes":208,"sent-packets":4,"send-errors":0,"sent-bytes":208,"collisions":0},` +
+	`{"name":"lo0","flags":"0x8049","network":"127.0.0.0/8","address":"127.0.0.1","received-packets":4,"received-bytes":208,"sent-packets":4,"sent-bytes":208},` +
+	`{"name":"epair0b","flags":"0x8863","mtu":1500,"network":"<Link#2>","address":"02:d4:5c:2c:6b:0b","received-packets":"17","received-errors":"0","dropped-packets":"0","received-bytes":"1830","sent-packets":"12","send-errors":"1","sent-bytes":"1004","collisions":"0"}` +
+	`]}}`
+
+func TestParseNetstat(t *testing.T) {
+	ifaces, err := parseNetstat([]byte(netstatSample))
+	require.NoError(t, err)
+	assert.Equal(t, []stats.NetworkInterface{{
+		Name:      "lo0",
+		RxBytes:   208,
+		RxPackets: 4,
+		TxBytes:   208,
+		TxPackets: 4,
+	}, {
+		Name:      "epair0b",
+		RxBytes:   1830,
+		RxPackets: 17,
+		TxBytes:   1004,
+		TxPackets: 12,
+		TxErrors:  1,
+	}}, ifaces)
+}
+
+func TestParseNetstatInvalid(t *testing.T) {
+	_, err := parseNetstat([]byte(`{}`))
+	assert.EqualError(t, err, "netstat: nil result")
+
+	_, err = parseNetstat([]byte(`{"statistics": {"interface": [{"name":"lo0","network":"<Link#1>","received-bytes":"many"}]}}`))
+	assert.ErrorContains(t, err, `netstat: invalid counter "many"`)
+}
@@ -1,9 +1,13 @@
 package jail
 
 import (
+	"errors"
 	"fmt"
 	"net/netip"
+	"slices"
 	"syscall"
+
+	runjspec "go.sbk.wtf/runj/runtimespec"
 )
 
 // CreateParams is a limited subset of the parameters available in jail.conf(5) for use with jail(8).
@@ -24,6 +28,125 @@ type CreateParams struct {
 	// EnforceStatfs controls mount visibility (0, 1, or 2); nil leaves the
 	// kernel default.
 	EnforceStatfs *int
+	// Allow holds the allow.* permission parameters
+	Allow AllowParams
+	// DevfsRuleset is the devfs ruleset enforced for devfs mounted inside
+	// the jail; 0 leaves the kernel default.
+	DevfsRuleset int
+	// SysVMsg, SysVSem, and SysVShm are the System V IPC modes ("new",
+	// "inherit", or "disable"); an empty value leaves the kernel default.
+	SysVMsg string
+	SysVSem string
+	SysVShm string
+}
+
+// AllowParams holds the allow.* parameters that grant privileges to processes
+// in the jail.  A false value leaves the kernel default.
+type AllowParams struct {
+	SetHostname   bool
+	RawSockets    bool
+	Chflags       bool
+	Quotas        bool
+	SocketAf      bool
+	Mlock         bool
+	ReservedPorts bool
+	Suser         bool
+	// Mount lists the file system types that may be mounted in the jail, each
+	// mapped to an allow.mount.<type> parameter.  Mounting requires
+	// enforce_statfs to be below 2.
+	Mount []string
+}
+
+func (a *AllowParams) iovec(enforceStatfs *int) ([]syscall.Iovec, error) {
+	iovec := make([]syscall.Iovec, 0)
+	for _, p := range []struct {
+		name  string
+		allow bool
+	}{
+		{"allow.set_hostname", a.SetHostname},
+		{"allow.raw_sockets", a.RawSockets},
+		{"allow.chflags", a.Chflags},
+		{"allow.quotas", a.Quotas},
+		{"allow.socket_af", a.SocketAf},
+		{"allow.mlock", a.Mlock},
+		{"allow.reserved_ports", a.ReservedPorts},
+		{"allow.suser", a.Suser},
+	} {
+		if !p.allow {
+			continue
+		}
+		allowio, err := nilIovec(p.name)
+		if err != nil {
+			return nil, err
+		}
+		iovec = append(iovec, allowio...)
+	}
+
+	if len(a.Mount) == 0 {
+		return iovec, nil
+	}
+	if enforceStatfs == nil || *enforceStatfs >= 2 {
+		return nil, errors.New("jail: validation failure: allow.mount requires enforce_statfs to be 0 or 1")
+	}
+	mountio, err := nilIovec("allow.mount")
+	if err != nil {
+		return nil, err
+	}
+	iovec = append(iovec, mountio...)
+	seen := make(map[string]bool)
+	for _, fstype := range a.Mount {
+		if !slices.Contains(runjspec.AllowMountTypes, fstype) {
+			return nil, fmt.Errorf("jail: unknown allow.mount type %q", fstype)
+		}
+		if seen[fstype] {
+			return nil, fmt.Errorf("jail: duplicate allow.mount type %q", fstype)
+		}
+		seen[fstype] = true
+		fstypeio, err := nilIovec("allow.mount." + fstype)
+		if err != nil {
+			return nil, err
+		}
+		iovec = append(iovec, fstypeio...)
+	}
+	return iovec, nil
+}
+
+// UpdateParams holds the parameters of an existing jail changed by Update
+type UpdateParams struct {
+	// EnforceStatfs is the new mount visibility (0, 1, or 2); nil leaves it
+	// unchanged
+	EnforceStatfs *int
+	// Allow grants additional privileges; a false value leaves the privilege
+	// unchanged
+	Allow AllowParams
+}
+
+// iovec encodes the parameters for the named jail, whose enforce_statfs is
+// currently enforceStatfs
+func (u *UpdateParams) iovec(name string, enforceStatfs int) ([]syscall.Iovec, error) {
+	iovec, err := stringIovec("name", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.EnforceStatfs != nil {
+		v := *u.EnforceStatfs
+		if v < 0 || v > 2 {
+			return nil, fmt.Errorf("jail: invalid enforce_statfs value %d (must be 0, 1, or 2)", v)
+		}
+		esio, err := int32Iovec("enforce_statfs", int32(v))
+		if err != nil {
+			return nil, err
+		}
+		iovec = append(iovec, esio...)
+		enforceStatfs = v
+	}
+
+	allowio, err := u.Allow.iovec(&enforceStatfs)
+	if err != nil {
+		return nil, err
+	}
+	return append(iovec, allowio...), nil
 }
 
 func (c *CreateParams) iovec() ([]syscall.Iovec, error) {
@@ -176,6 +299,34 @@ func (c *CreateParams) iovec() ([]syscall.Iovec, error) {
 		iovec = append(iovec, ip6Addrio...)
 	}
 
+	for _, sysv := range []struct {
+		param, field, mode string
+	}{
+		{"sysvmsg", "SysVMsg", c.SysVMsg},
+		{"sysvsem", "SysVSem", c.SysVSem},
+		{"sysvshm", "SysVShm", c.SysVShm},
+	} {
+		if sysv.mode == "" {
+			continue
+		}
+		var mode int32
+		switch sysv.mode {
+		case "disable":
+			mode = 0
+		case "new":
+			mode = 1
+		case "inherit":
+			mode = 2
+		default:
+			return nil, fmt.Errorf("jail: unknown %s type %q", sysv.field, sysv.mode)
+		}
+		sysvio, err := int32Iovec(sysv.param, mode)
+		if err != nil {
+			return nil, err
+		}
+		iovec = append(iovec, sysvio...)
+	}
+
 	if c.EnforceStatfs != nil {
 		v := *c.EnforceStatfs
 		if v < 0 || v > 2 {
@@ -188,6 +339,23 @@ func (c *CreateParams) iovec() ([]syscall.Iovec, error) {
 		iovec = append(iovec, esio...)
 	}
 
+	if c.DevfsRuleset != 0 {
+		if c.DevfsRuleset < 0 || c.DevfsRuleset > devfsRulesetMax {
+			return nil, fmt.Errorf("jail: invalid devfs_ruleset value %d", c.DevfsRuleset)
+		}
+		rulesetio, err := int32Iovec("devfs_ruleset", int32(c.DevfsRuleset))
+		if err != nil {
+			return nil, err
+		}
+		iovec = append(iovec, rulesetio...)
+	}
+
+	allowio, err := c.Allow.iovec(c.EnforceStatfs)
+	if err != nil {
+		return nil, err
+	}
+	iovec = append(iovec, allowio...)
+
 	persist, err := nilIovec("persist")
 	if err != nil {
 		return nil, err
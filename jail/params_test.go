@@ -362,6 +362,40 @@ func TestCreateParamsIovec(t *testing.T) {
 		}, {
 			name: "persist\x00",
 		}},
+	}, {
+		name: "sysv",
+		config: CreateParams{
+			Name:    "sysv",
+			Root:    "/tmp/test/sysv/root",
+			SysVMsg: "new",
+			SysVSem: "inherit",
+			SysVShm: "disable",
+		},
+		iovec: []fakeIovec{{
+			name: "name\x00",
+			val:  []byte("sysv\x00"),
+		}, {
+			name: "path\x00",
+			val:  []byte("/tmp/test/sysv/root\x00"),
+		}, {
+			name: "sysvmsg\x00",
+			val:  []byte{1, 0, 0, 0},
+		}, {
+			name: "sysvsem\x00",
+			val:  []byte{2, 0, 0, 0},
+		}, {
+			name: "sysvshm\x00",
+			val:  []byte{0, 0, 0, 0},
+		}, {
+			name: "persist\x00",
+		}},
+	}, {
+		name: "sysvshm-invalid",
+		config: CreateParams{
+			Name:    "sysvshm-invalid",
+			SysVShm: "shared",
+		},
+		err: errors.New(`jail: unknown SysVShm type "shared"`),
 	}, {
 		name: "ip4.addr-invalid",
 		config: CreateParams{
@@ -404,6 +438,130 @@ func TestCreateParamsIovec(t *testing.T) {
 			VNet: "disable",
 		},
 		err: errors.New(`jail: unknown VNet type "disable"`),
+	}, {
+		name: "devfs-ruleset",
+		config: CreateParams{
+			Name:         "devfs",
+			Root:         "/tmp/test/devfs/root",
+			DevfsRuleset: 1000,
+		},
+		iovec: []fakeIovec{{
+			name: "name\x00",
+			val:  []byte("devfs\x00"),
+		}, {
+			name: "path\x00",
+			val:  []byte("/tmp/test/devfs/root\x00"),
+		}, {
+			name: "devfs_ruleset\x00",
+			val:  []byte{0xe8, 0x03, 0, 0},
+		}, {
+			name: "persist\x00",
+		}},
+	}, {
+		name: "devfs-ruleset-invalid",
+		config: CreateParams{
+			Name:         "devfs",
+			DevfsRuleset: 70000,
+		},
+		err: errors.New("jail: invalid devfs_ruleset value 70000"),
+	}, {
+		name: "allow",
+		config: CreateParams{
+			Name: "allow",
+			Root: "/tmp/test/allow/root",
+			Allow: AllowParams{
+				SetHostname:   true,
+				RawSockets:    true,
+				Chflags:       true,
+				Quotas:        true,
+				SocketAf:      true,
+				Mlock:         true,
+				ReservedPorts: true,
+				Suser:         true,
+			},
+		},
+		iovec: []fakeIovec{{
+			name: "name\x00",
+			val:  []byte("allow\x00"),
+		}, {
+			name: "path\x00",
+			val:  []byte("/tmp/test/allow/root\x00"),
+		}, {
+			name: "allow.set_hostname\x00",
+		}, {
+			name: "allow.raw_sockets\x00",
+		}, {
+			name: "allow.chflags\x00",
+		}, {
+			name: "allow.quotas\x00",
+		}, {
+			name: "allow.socket_af\x00",
+		}, {
+			name: "allow.mlock\x00",
+		}, {
+			name: "allow.reserved_ports\x00",
+		}, {
+			name: "allow.suser\x00",
+		}, {
+			name: "persist\x00",
+		}},
+	}, {
+		name: "allow-mount",
+		config: CreateParams{
+			Name:          "allow-mount",
+			Root:          "/tmp/test/allow-mount/root",
+			EnforceStatfs: intPtr(1),
+			Allow:         AllowParams{Mount: []string{"tmpfs", "nullfs"}},
+		},
+		iovec: []fakeIovec{{
+			name: "name\x00",
+			val:  []byte("allow-mount\x00"),
+		}, {
+			name: "path\x00",
+			val:  []byte("/tmp/test/allow-mount/root\x00"),
+		}, {
+			name: "enforce_statfs\x00",
+			val:  []byte{1, 0, 0, 0},
+		}, {
+			name: "allow.mount\x00",
+		}, {
+			name: "allow.mount.tmpfs\x00",
+		}, {
+			name: "allow.mount.nullfs\x00",
+		}, {
+			name: "persist\x00",
+		}},
+	}, {
+		name: "allow-mount-enforce-statfs-default",
+		config: CreateParams{
+			Name:  "allow-mount",
+			Allow: AllowParams{Mount: []string{"tmpfs"}},
+		},
+		err: errors.New("jail: validation failure: allow.mount requires enforce_statfs to be 0 or 1"),
+	}, {
+		name: "allow-mount-enforce-statfs-2",
+		config: CreateParams{
+			Name:          "allow-mount",
+			EnforceStatfs: intPtr(2),
+			Allow:         AllowParams{Mount: []string{"tmpfs"}},
+		},
+		err: errors.New("jail: validation failure: allow.mount requires enforce_statfs to be 0 or 1"),
+	}, {
+		name: "allow-mount-unknown",
+		config: CreateParams{
+			Name:          "allow-mount",
+			EnforceStatfs: intPtr(0),
+			Allow:         AllowParams{Mount: []string{"ext4"}},
+		},
+		err: errors.New(`jail: unknown allow.mount type "ext4"`),
+	}, {
+		name: "allow-mount-duplicate",
+		config: CreateParams{
+			Name:          "allow-mount",
+			EnforceStatfs: intPtr(0),
+			Allow:         AllowParams{Mount: []string{"tmpfs", "tmpfs"}},
+		},
+		err: errors.New(`jail: duplicate allow.mount type "tmpfs"`),
 	}}
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
@@ -421,6 +579,98 @@ func TestCreateParamsIovec(t *testing.T) {
 	}
 }
 
+func TestUpdateParamsIovec(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        UpdateParams
+		enforceStatfs int
+		iovec         []fakeIovec
+		err           error
+	}{{
+		name:          "empty",
+		enforceStatfs: 2,
+		iovec: []fakeIovec{{
+			name: "name\x00",
+			val:  []byte("update\x00"),
+		}},
+	}, {
+		name:          "enforce-statfs",
+		config:        UpdateParams{EnforceStatfs: intPtr(1)},
+		enforceStatfs: 2,
+		iovec: []fakeIovec{{
+			name: "name\x00",
+			val:  []byte("update\x00"),
+		}, {
+			name: "enforce_statfs\x00",
+			val:  []byte{1, 0, 0, 0},
+		}},
+	}, {
+		name:          "enforce-statfs-invalid",
+		config:        UpdateParams{EnforceStatfs: intPtr(-1)},
+		enforceStatfs: 2,
+		err:           errors.New("jail: invalid enforce_statfs value -1 (must be 0, 1, or 2)"),
+	}, {
+		name:          "allow",
+		config:        UpdateParams{Allow: AllowParams{RawSockets: true}},
+		enforceStatfs: 2,
+		iovec: []fakeIovec{{
+			name: "name\x00",
+			val:  []byte("update\x00"),
+		}, {
+			name: "allow.raw_sockets\x00",
+		}},
+	}, {
+		name:          "allow-mount-current-enforce-statfs",
+		config:        UpdateParams{Allow: AllowParams{Mount: []string{"tmpfs"}}},
+		enforceStatfs: 1,
+		iovec: []fakeIovec{{
+			name: "name\x00",
+			val:  []byte("update\x00"),
+		}, {
+			name: "allow.mount\x00",
+		}, {
+			name: "allow.mount.tmpfs\x00",
+		}},
+	}, {
+		name:          "allow-mount-current-enforce-statfs-2",
+		config:        UpdateParams{Allow: AllowParams{Mount: []string{"tmpfs"}}},
+		enforceStatfs: 2,
+		err:           errors.New("jail: validation failure: allow.mount requires enforce_statfs to be 0 or 1"),
+	}, {
+		name: "allow-mount-lowered-enforce-statfs",
+		config: UpdateParams{
+			EnforceStatfs: intPtr(0),
+			Allow:         AllowParams{Mount: []string{"nullfs"}},
+		},
+		enforceStatfs: 2,
+		iovec: []fakeIovec{{
+			name: "name\x00",
+			val:  []byte("update\x00"),
+		}, {
+			name: "enforce_statfs\x00",
+			val:  []byte{0, 0, 0, 0},
+		}, {
+			name: "allow.mount\x00",
+		}, {
+			name: "allow.mount.nullfs\x00",
+		}},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.config.iovec("update", tc.enforceStatfs)
+			if tc.err != nil {
+				assert.Error(t, err, tc.err)
+				assert.Equal(t, tc.err.Error(), err.Error())
+				return
+			}
+			assert.NoError(t, err, "iovec")
+			converted, err := toFakeIovec(actual)
+			assert.NoError(t, err, "toFakeIovec")
+			assert.EqualValues(t, tc.iovec, converted)
+		})
+	}
+}
+
 func toFakeIovec(actual []syscall.Iovec) ([]fakeIovec, error) {
 	if len(actual)%2 != 0 {
 		return nil, fmt.Errorf("expected even number of iovecs, got %d", len(actual))
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"strconv"
+	"strings"
 )
 
 // IsRunning attempts to determine whether a given jail is running.  This is
@@ -34,29 +36,83 @@ func IsRunning(ctx context.Context, jail string, pid int) (bool, error) {
 	return ok, nil
 }
 
+// Process describes a process running in a jail
+type Process struct {
+	PID      int    `json:"pid"`
+	Terminal string `json:"tty"`
+	State    string `json:"state"`
+	CPUTime  string `json:"time"`
+	Command  string `json:"command"`
+}
+
+// Processes lists the processes running in a jail.  Like IsRunning, it
+// depends on the host's "ps" command.
+func Processes(ctx context.Context, jail string) ([]Process, error) {
+	// -ww keeps the command lines from being truncated
+	procs, err := psProcesses(exec.CommandContext(ctx, "ps", "--libxo", "json", "-x", "-ww", "-J", jail))
+	if err != nil {
+		return nil, err
+	}
+	return parseProcesses(procs)
+}
+
+// parseProcesses converts the processes in the output of ps
+func parseProcesses(procs []psProcess) ([]Process, error) {
+	processes := make([]Process, 0, len(procs))
+	for _, p := range procs {
+		pid, err := strconv.Atoi(strings.TrimSpace(p.PID))
+		if err != nil {
+			return nil, fmt.Errorf("ps: invalid pid %q: %w", p.PID, err)
+		}
+		processes = append(processes, Process{
+			PID:      pid,
+			Terminal: strings.TrimSpace(p.TerminalName),
+			State:    p.State,
+			CPUTime:  p.CPUTime,
+			Command:  p.Command,
+		})
+	}
+	return processes, nil
+}
+
 // psCmd executes a "ps" command provided as an *exec.Cmd and output with libxo
 // json and parses the result to determine whether any processes are running.
 func psCmd(cmd *exec.Cmd) (bool, error) {
+	procs, err := psProcesses(cmd)
+	if err != nil {
+		return false, err
+	}
+	return len(procs) > 0, nil
+}
+
+// psProcesses executes a "ps" command provided as an *exec.Cmd and output with
+// libxo json and returns the processes in the result
+func psProcesses(cmd *exec.Cmd) ([]psProcess, error) {
 	cmd.Stderr = os.Stderr
 	out, err := cmd.Output()
 	if err != nil {
 		// `ps` exits with 1 when there are no processes, which is a valid state
 		if ee, ok := err.(*exec.ExitError); ok {
 			if ee.ExitCode() == 1 {
-				return false, nil
+				return nil, nil
 			}
 		}
-		return false, err
+		return nil, err
 	}
+	return parsePs(out)
+}
+
+// parsePs parses the libxo json output of ps
+func parsePs(out []byte) ([]psProcess, error) {
 	result := &psOutput{}
-	err = json.Unmarshal(out, result)
+	err := json.Unmarshal(out, result)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	if result == nil || result.ProcessInformation == nil {
-		return false, errors.New("nil result")
+		return nil, errors.New("nil result")
 	}
-	return len(result.ProcessInformation.Processes) > 0, nil
+	return result.ProcessInformation.Processes, nil
 }
 
 type psOutput struct {
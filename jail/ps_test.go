@@ -0,0 +1,50 @@
+package jail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// psSample is the output of `ps --libxo json -x -ww -J <jail>`
+const psSample = `{"__version": "1", "process-information": {"process": [` +
+	`{"pid":"41093","terminal-name":"0 ","state":"Ss","cpu-time":"0:00.01","command":"/bin/sh"},` +
+	`{"pid":"41120","terminal-name":"- ","state":"S","cpu-time":"0:00.00","command":"sleep 600"}` +
+	`]}}`
+
+func TestParsePs(t *testing.T) {
+	procs, err := parsePs([]byte(psSample))
+	require.NoError(t, err)
+	processes, err := parseProcesses(procs)
+	require.NoError(t, err)
+	assert.Equal(t, []Process{{
+		PID:      41093,
+		Terminal: "0",
+		State:    "Ss",
+		CPUTime:  "0:00.01",
+		Command:  "/bin/sh",
+	}, {
+		PID:      41120,
+		Terminal: "-",
+		State:    "S",
+		CPUTime:  "0:00.00",
+		Command:  "sleep 600",
+	}}, processes)
+}
+
+func TestParsePsEmpty(t *testing.T) {
+	procs, err := parsePs([]byte(`{"__version": "1", "process-information": {"process": []}}`))
+	require.NoError(t, err)
+	processes, err := parseProcesses(procs)
+	require.NoError(t, err)
+	assert.Empty(t, processes)
+}
+
+func TestParsePsInvalid(t *testing.T) {
+	_, err := parsePs([]byte(`{}`))
+	assert.EqualError(t, err, "nil result")
+
+	_, err = parseProcesses([]psProcess{{PID: "abc"}})
+	assert.ErrorContains(t, err, `ps: invalid pid "abc"`)
+}
@@ -0,0 +1,85 @@
+package jail
+
+import (
+	"fmt"
+	"math"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+)
+
+// rlimitResources maps OCI rlimit types to the FreeBSD resource numbers from
+// <sys/resource.h>.  The spec names its rlimits after Linux, so types that only
+// exist on Linux (such as RLIMIT_NICE or RLIMIT_RTPRIO) are absent and types
+// that only exist on FreeBSD (such as RLIMIT_SBSIZE) are added with their
+// FreeBSD names.
+var rlimitResources = map[string]int{
+	"RLIMIT_CPU":     0,
+	"RLIMIT_FSIZE":   1,
+	"RLIMIT_DATA":    2,
+	"RLIMIT_STACK":   3,
+	"RLIMIT_CORE":    4,
+	"RLIMIT_RSS":     5,
+	"RLIMIT_MEMLOCK": 6,
+	"RLIMIT_NPROC":   7,
+	"RLIMIT_NOFILE":  8,
+	"RLIMIT_SBSIZE":  9,
+	"RLIMIT_VMEM":    10,
+	"RLIMIT_AS":      10, // RLIMIT_AS is an alias for RLIMIT_VMEM
+	"RLIMIT_NPTS":    11,
+	"RLIMIT_SWAP":    12,
+	"RLIMIT_KQUEUES": 13,
+	"RLIMIT_UMTXP":   14,
+}
+
+// rlimInfinity is RLIM_INFINITY from <sys/resource.h>
+const rlimInfinity = math.MaxInt64
+
+// ValidateRlimits checks that every rlimit type can be mapped to a FreeBSD
+// resource, that no resource is specified twice, and that no soft limit exceeds
+// its hard limit.
+func ValidateRlimits(rlimits []runtimespec.POSIXRlimit) error {
+	seen := make(map[int]string)
+	for _, rlimit := range rlimits {
+		resource, ok := rlimitResources[rlimit.Type]
+		if !ok {
+			return fmt.Errorf("rlimit: unsupported type %q", rlimit.Type)
+		}
+		if prev, ok := seen[resource]; ok {
+			return fmt.Errorf("rlimit: duplicate type %q (already set as %q)", rlimit.Type, prev)
+		}
+		seen[resource] = rlimit.Type
+		if rlimit.Soft > rlimit.Hard {
+			return fmt.Errorf("rlimit: %s soft limit %d exceeds hard limit %d", rlimit.Type, rlimit.Soft, rlimit.Hard)
+		}
+	}
+	return nil
+}
+
+// SetRlimits applies rlimits to the current process with setrlimit(2).  Raising
+// a hard limit requires root, so SetRlimits should be called before changing to
+// an unprivileged user.
+func SetRlimits(rlimits []runtimespec.POSIXRlimit) error {
+	if err := ValidateRlimits(rlimits); err != nil {
+		return err
+	}
+	for _, rlimit := range rlimits {
+		lim := &unix.Rlimit{
+			Cur: rlimValue(rlimit.Soft),
+			Max: rlimValue(rlimit.Hard),
+		}
+		if err := unix.Setrlimit(rlimitResources[rlimit.Type], lim); err != nil {
+			return fmt.Errorf("rlimit: failed to set %s: %w", rlimit.Type, err)
+		}
+	}
+	return nil
+}
+
+// rlimValue converts an OCI rlimit value to a FreeBSD rlim_t, which is signed.
+// Values beyond its range are treated as RLIM_INFINITY.
+func rlimValue(v uint64) int64 {
+	if v > rlimInfinity {
+		return rlimInfinity
+	}
+	return int64(v)
+}
@@ -0,0 +1,73 @@
+package jail
+
+import (
+	"math"
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRlimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		rlimits []runtimespec.POSIXRlimit
+		err     string
+	}{{
+		name: "empty",
+	}, {
+		name: "common",
+		rlimits: []runtimespec.POSIXRlimit{
+			{Type: "RLIMIT_NOFILE", Soft: 1024, Hard: 4096},
+			{Type: "RLIMIT_NPROC", Soft: 64, Hard: 64},
+			{Type: "RLIMIT_CORE", Soft: 0, Hard: 0},
+			{Type: "RLIMIT_MEMLOCK", Soft: 65536, Hard: 65536},
+		},
+	}, {
+		name: "freebsd-only",
+		rlimits: []runtimespec.POSIXRlimit{
+			{Type: "RLIMIT_SBSIZE", Soft: 1 << 20, Hard: 1 << 20},
+			{Type: "RLIMIT_SWAP", Soft: 1 << 30, Hard: 1 << 30},
+			{Type: "RLIMIT_NPTS", Soft: 4, Hard: 4},
+			{Type: "RLIMIT_KQUEUES", Soft: 16, Hard: 16},
+			{Type: "RLIMIT_UMTXP", Soft: 128, Hard: 128},
+		},
+	}, {
+		name:    "linux-only",
+		rlimits: []runtimespec.POSIXRlimit{{Type: "RLIMIT_RTPRIO", Soft: 1, Hard: 1}},
+		err:     `rlimit: unsupported type "RLIMIT_RTPRIO"`,
+	}, {
+		name: "duplicate",
+		rlimits: []runtimespec.POSIXRlimit{
+			{Type: "RLIMIT_NOFILE", Soft: 1024, Hard: 1024},
+			{Type: "RLIMIT_NOFILE", Soft: 2048, Hard: 2048},
+		},
+		err: `rlimit: duplicate type "RLIMIT_NOFILE" (already set as "RLIMIT_NOFILE")`,
+	}, {
+		name: "duplicate-alias",
+		rlimits: []runtimespec.POSIXRlimit{
+			{Type: "RLIMIT_VMEM", Soft: 1024, Hard: 1024},
+			{Type: "RLIMIT_AS", Soft: 2048, Hard: 2048},
+		},
+		err: `rlimit: duplicate type "RLIMIT_AS" (already set as "RLIMIT_VMEM")`,
+	}, {
+		name:    "soft-exceeds-hard",
+		rlimits: []runtimespec.POSIXRlimit{{Type: "RLIMIT_NOFILE", Soft: 2048, Hard: 1024}},
+		err:     "rlimit: RLIMIT_NOFILE soft limit 2048 exceeds hard limit 1024",
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateRlimits(tc.rlimits)
+			if tc.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestRlimValue(t *testing.T) {
+	assert.Equal(t, int64(1024), rlimValue(1024))
+	assert.Equal(t, int64(math.MaxInt64), rlimValue(math.MaxUint64))
+}
@@ -14,6 +14,7 @@ import (
 
 const (
 	_FLAG_CREATE = 0x01
+	_FLAG_UPDATE = 0x02
 )
 
 // ID identifies jails
@@ -80,6 +81,40 @@ func (f *findParams) iovec() ([]syscall.Iovec, error) {
 	return iovec, nil
 }
 
+// getInt32 reads the named integer parameters of the jail with SYS_JAIL_GET
+func getInt32(jid ID, names ...string) ([]int32, error) {
+	iovec, err := int32Iovec("jid", int32(jid))
+	if err != nil {
+		return nil, err
+	}
+	values := make([]int32, len(names))
+	for i, name := range names {
+		n, err := syscall.ByteSliceFromString(name)
+		if err != nil {
+			return nil, err
+		}
+		iovec = append(iovec, makeIovec(n, (*byte)(unsafe.Pointer(&values[i])), 4)...)
+	}
+	if _, err := get(iovec, 0); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// setInt32 updates an integer parameter of the jail with SYS_JAIL_SET
+func setInt32(jid ID, name string, value int32) error {
+	iovec, err := int32Iovec("jid", int32(jid))
+	if err != nil {
+		return err
+	}
+	param, err := int32Iovec(name, value)
+	if err != nil {
+		return err
+	}
+	_, err = set(append(iovec, param...), _FLAG_UPDATE)
+	return err
+}
+
 // get calls SYS_JAIL_GET
 func get(iovecs []syscall.Iovec, flags int) (ID, error) {
 	return iovSyscall(syscall.SYS_JAIL_GET, iovecs, flags)
@@ -26,7 +26,7 @@ func MoveVNetInterfaces(ctx context.Context, ociConfig *runtimespec.Spec, j Jail
 	if len(ociConfig.FreeBSD.Jail.VnetInterfaces) == 0 {
 		return nil
 	}
-	if j.id() == 0 {
+	if j.ID() == 0 {
 		return errors.New("cannot move vnet interface to jail 0")
 	}
 	vnetArg := "vnet"
@@ -34,7 +34,7 @@ func MoveVNetInterfaces(ctx context.Context, ociConfig *runtimespec.Spec, j Jail
 		vnetArg = "-vnet"
 	}
 	for _, iface := range ociConfig.FreeBSD.Jail.VnetInterfaces {
-		cmd := exec.CommandContext(ctx, filepath.Clean(ifconfig), iface, vnetArg, j.id().String())
+		cmd := exec.CommandContext(ctx, filepath.Clean(ifconfig), iface, vnetArg, j.ID().String())
 		out, err := cmd.CombinedOutput()
 		if err != nil {
 			return fmt.Errorf("ifconfig: %q: %w", out, err)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"go.sbk.wtf/runj/internal/util"
@@ -54,25 +55,37 @@ func LoadConfig(id string) (*runtimespec.Spec, error) {
 	if err != nil {
 		return nil, err
 	}
-	if _, err = os.Stat(filepath.Join(state.Dir(id), RunjExtensionFileName)); err == nil {
-		extData, err := os.ReadFile(filepath.Join(state.Dir(id), RunjExtensionFileName))
-		if err != nil {
-			return nil, err
-		}
-		freebsd := &runjspec.FreeBSD{}
-		err = json.Unmarshal(extData, freebsd)
-		if err != nil {
-			return nil, err
-		}
-		merge(config, freebsd)
+	freebsd, err := LoadExtension(id)
+	if err != nil {
+		return nil, err
 	}
+	merge(config, freebsd)
 	return config, nil
 }
 
+// LoadExtension loads the runj extension file stored in the state directory.
+// nil is returned when the bundle did not include one.
+func LoadExtension(id string) (*runjspec.FreeBSD, error) {
+	if _, err := os.Stat(filepath.Join(state.Dir(id), RunjExtensionFileName)); err != nil {
+		return nil, nil
+	}
+	extData, err := os.ReadFile(filepath.Join(state.Dir(id), RunjExtensionFileName))
+	if err != nil {
+		return nil, err
+	}
+	freebsd := &runjspec.FreeBSD{}
+	err = json.Unmarshal(extData, freebsd)
+	if err != nil {
+		return nil, err
+	}
+	return freebsd, nil
+}
+
 // merge processes an existing spec and additional FreeBSD section to merge them
 // together.  Fields specified in the original spec are preserved except in the
 // case where they are overwritten.  Slices the FreeBSD section are appended to
-// slices specified in the original spec.
+// slices specified in the original spec.  Permissions granted in either are
+// granted in the result.
 func merge(spec *runtimespec.Spec, freebsd *runjspec.FreeBSD) {
 	if spec == nil || freebsd == nil {
 		return
@@ -101,4 +114,34 @@ func merge(spec *runtimespec.Spec, freebsd *runjspec.FreeBSD) {
 			}
 		}
 	}
+	if freebsd.Allow != nil {
+		if spec.FreeBSD.Jail.Allow == nil {
+			spec.FreeBSD.Jail.Allow = &runtimespec.FreeBSDJailAllow{}
+		}
+		allow := spec.FreeBSD.Jail.Allow
+		allow.SetHostname = allow.SetHostname || freebsd.Allow.SetHostname
+		allow.RawSockets = allow.RawSockets || freebsd.Allow.RawSockets
+		allow.Chflags = allow.Chflags || freebsd.Allow.Chflags
+		allow.Quotas = allow.Quotas || freebsd.Allow.Quotas
+		allow.SocketAf = allow.SocketAf || freebsd.Allow.SocketAf
+		allow.Mlock = allow.Mlock || freebsd.Allow.Mlock
+		allow.ReservedPorts = allow.ReservedPorts || freebsd.Allow.ReservedPorts
+		allow.Suser = allow.Suser || freebsd.Allow.Suser
+		for _, fstype := range freebsd.Allow.Mount {
+			if !slices.Contains(allow.Mount, fstype) {
+				allow.Mount = append(allow.Mount, fstype)
+			}
+		}
+	}
+	if freebsd.IPC != nil {
+		if freebsd.IPC.SysVMsg != "" {
+			spec.FreeBSD.Jail.SysVMsg = runtimespec.FreeBSDSharing(freebsd.IPC.SysVMsg)
+		}
+		if freebsd.IPC.SysVSem != "" {
+			spec.FreeBSD.Jail.SysVSem = runtimespec.FreeBSDSharing(freebsd.IPC.SysVSem)
+		}
+		if freebsd.IPC.SysVShm != "" {
+			spec.FreeBSD.Jail.SysVShm = runtimespec.FreeBSDSharing(freebsd.IPC.SysVShm)
+		}
+	}
 }
@@ -21,6 +21,12 @@ func TestMergeEmpty(t *testing.T) {
 	assert.DeepEqual(t, spec.FreeBSD.Jail.VnetInterfaces, freebsd.Network.VNet.Interfaces)
 	assert.Equal(t, string(spec.FreeBSD.Jail.Ip4), string(freebsd.Network.IPv4.Mode))
 	assert.DeepEqual(t, spec.FreeBSD.Jail.Ip4Addr, freebsd.Network.IPv4.Addr)
+	assert.Equal(t, spec.FreeBSD.Jail.Allow.RawSockets, freebsd.Allow.RawSockets)
+	assert.Equal(t, spec.FreeBSD.Jail.Allow.Chflags, freebsd.Allow.Chflags)
+	assert.DeepEqual(t, spec.FreeBSD.Jail.Allow.Mount, freebsd.Allow.Mount)
+	assert.Equal(t, string(spec.FreeBSD.Jail.SysVMsg), string(freebsd.IPC.SysVMsg))
+	assert.Equal(t, string(spec.FreeBSD.Jail.SysVSem), string(freebsd.IPC.SysVSem))
+	assert.Equal(t, string(spec.FreeBSD.Jail.SysVShm), string(freebsd.IPC.SysVShm))
 }
 
 // TestMergeNilArguments verifies that merge tolerates nil inputs without
@@ -116,3 +122,67 @@ func TestMergeEmptyModePreservesExisting(t *testing.T) {
 	assert.Equal(t, string(spec.FreeBSD.Jail.Ip4), "inherit")
 	assert.DeepEqual(t, spec.FreeBSD.Jail.Ip4Addr, []string{"10.2.2.2"})
 }
+
+// TestMergeAllow verifies that permissions granted in either the spec or the
+// FreeBSD section are granted after merging, and that mount types are appended.
+func TestMergeAllow(t *testing.T) {
+	spec := &runtimespec.Spec{
+		FreeBSD: &runtimespec.FreeBSD{
+			Jail: &runtimespec.FreeBSDJail{
+				Allow: &runtimespec.FreeBSDJailAllow{
+					Chflags: true,
+					Mount:   []string{"tmpfs"},
+				},
+			},
+		},
+	}
+	merge(spec, &runjspec.FreeBSD{
+		Allow: &runjspec.FreeBSDAllow{
+			RawSockets: true,
+			Mount:      []string{"nullfs"},
+		},
+	})
+	assert.DeepEqual(t, spec.FreeBSD.Jail.Allow, &runtimespec.FreeBSDJailAllow{
+		Chflags:    true,
+		RawSockets: true,
+		Mount:      []string{"tmpfs", "nullfs"},
+	})
+}
+
+func TestMergeAllowMountOverlap(t *testing.T) {
+	spec := &runtimespec.Spec{
+		FreeBSD: &runtimespec.FreeBSD{
+			Jail: &runtimespec.FreeBSDJail{
+				Allow: &runtimespec.FreeBSDJailAllow{
+					Mount: []string{"tmpfs", "nullfs"},
+				},
+			},
+		},
+	}
+	merge(spec, &runjspec.FreeBSD{
+		Allow: &runjspec.FreeBSDAllow{
+			Mount: []string{"nullfs", "procfs", "procfs"},
+		},
+	})
+	assert.DeepEqual(t, spec.FreeBSD.Jail.Allow.Mount, []string{"tmpfs", "nullfs", "procfs"})
+}
+
+func TestMergeIPC(t *testing.T) {
+	spec := &runtimespec.Spec{
+		FreeBSD: &runtimespec.FreeBSD{
+			Jail: &runtimespec.FreeBSDJail{
+				SysVMsg: runtimespec.FreeBSDShareDisable,
+				SysVSem: runtimespec.FreeBSDShareDisable,
+			},
+		},
+	}
+	merge(spec, &runjspec.FreeBSD{
+		IPC: &runjspec.FreeBSDIPC{
+			SysVSem: runjspec.FreeBSDSysVModeNew,
+			SysVShm: runjspec.FreeBSDSysVModeInherit,
+		},
+	})
+	assert.Equal(t, spec.FreeBSD.Jail.SysVMsg, runtimespec.FreeBSDShareDisable)
+	assert.Equal(t, spec.FreeBSD.Jail.SysVSem, runtimespec.FreeBSDShareNew)
+	assert.Equal(t, spec.FreeBSD.Jail.SysVShm, runtimespec.FreeBSDShareInherit)
+}
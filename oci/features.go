@@ -0,0 +1,83 @@
+package oci
+
+import (
+	"maps"
+	"slices"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/opencontainers/runtime-spec/specs-go/features"
+
+	"go.sbk.wtf/runj/rctl"
+	runjspec "go.sbk.wtf/runj/runtimespec"
+)
+
+// minOCIVersion is the oldest version of the runtime specification whose
+// configs runj accepts
+const minOCIVersion = "1.0.0"
+
+// Features describes the configuration runj supports, in the format defined by
+// the features document of the runtime specification.  The FreeBSD section is a
+// runj extension, as the specification does not define one yet.
+type Features struct {
+	features.Features
+	FreeBSD *FreeBSDFeatures `json:"freebsd,omitempty"`
+}
+
+// FreeBSDFeatures describes the supported FreeBSD-specific configuration
+type FreeBSDFeatures struct {
+	// Fields lists the supported fields of the freebsd section
+	Fields []string `json:"fields,omitempty"`
+	// Jail lists the supported fields of freebsd.jail
+	Jail []string `json:"jail,omitempty"`
+	// AllowMount lists the file system types that can be named in
+	// freebsd.jail.allow.mount
+	AllowMount []string `json:"allowMount,omitempty"`
+	// MountTypeOptions lists, for each file system type, the mount options
+	// accepted in addition to the mountOptions that apply to every type
+	MountTypeOptions map[string][]string `json:"mountTypeOptions,omitempty"`
+	// Resources lists the rctl(8) resources that can be limited
+	Resources []string `json:"resources,omitempty"`
+	// LinuxResources lists the linux.resources fields translated into
+	// rctl(8) limits
+	LinuxResources []string `json:"linuxResources,omitempty"`
+}
+
+// GetFeatures returns the features document for runj.  It is built from the
+// same lists that Validate checks configs against.
+func GetFeatures() *Features {
+	f := &Features{
+		Features: features.Features{
+			OCIVersionMin:                      minOCIVersion,
+			OCIVersionMax:                      runtimespec.Version,
+			Hooks:                              slices.Clone(supportedHooks),
+			MountOptions:                       slices.Sorted(maps.Keys(mountOptions)),
+			PotentiallyUnsafeConfigAnnotations: []string{PermissiveAnnotation},
+		},
+		FreeBSD: &FreeBSDFeatures{
+			Fields:           slices.Clone(supportedFreeBSD),
+			Jail:             slices.Clone(supportedJail),
+			AllowMount:       slices.Clone(runjspec.AllowMountTypes),
+			MountTypeOptions: make(map[string][]string),
+		},
+	}
+	for fstype, options := range typedMountOptions {
+		f.FreeBSD.MountTypeOptions[fstype] = slices.Sorted(maps.Keys(options))
+	}
+	for _, r := range rctl.Resources() {
+		f.FreeBSD.Resources = append(f.FreeBSD.Resources, string(r))
+	}
+	for _, group := range []struct {
+		name   string
+		fields []string
+	}{
+		{"memory", supportedMemory},
+		{"cpu", supportedCPU},
+		{"pids", supportedPids},
+		{"blockIO", supportedBlockIO},
+	} {
+		for _, field := range group.fields {
+			f.FreeBSD.LinuxResources = append(f.FreeBSD.LinuxResources, group.name+"."+field)
+		}
+	}
+	return f
+}
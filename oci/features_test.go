@@ -0,0 +1,47 @@
+package oci
+
+import (
+	"encoding/json"
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestGetFeatures(t *testing.T) {
+	f := GetFeatures()
+	assert.Equal(t, f.OCIVersionMin, "1.0.0")
+	assert.Equal(t, f.OCIVersionMax, runtimespec.Version)
+	assert.DeepEqual(t, f.Hooks, supportedHooks)
+	assert.Check(t, is.Contains(f.MountOptions, "nosuid"))
+	assert.DeepEqual(t, f.PotentiallyUnsafeConfigAnnotations, []string{PermissiveAnnotation})
+	assert.Assert(t, f.Linux == nil)
+
+	assert.Assert(t, f.FreeBSD != nil)
+	assert.DeepEqual(t, f.FreeBSD.Fields, []string{"devices", "jail"})
+	assert.Check(t, is.Contains(f.FreeBSD.Jail, "parent"))
+	assert.Check(t, is.Contains(f.FreeBSD.AllowMount, "tmpfs"))
+	assert.DeepEqual(t, f.FreeBSD.MountTypeOptions["nullfs"], []string{"cache", "nocache"})
+	assert.Check(t, is.Contains(f.FreeBSD.Resources, "memoryuse"))
+	assert.DeepEqual(t, f.FreeBSD.LinuxResources, []string{
+		"memory.limit",
+		"cpu.quota",
+		"cpu.period",
+		"pids.limit",
+		"blockIO.throttleReadBpsDevice",
+		"blockIO.throttleWriteBpsDevice",
+		"blockIO.throttleReadIOPSDevice",
+		"blockIO.throttleWriteIOPSDevice",
+	})
+}
+
+func TestGetFeaturesJSON(t *testing.T) {
+	data, err := json.Marshal(GetFeatures())
+	assert.NilError(t, err)
+	var doc map[string]json.RawMessage
+	assert.NilError(t, json.Unmarshal(data, &doc))
+	for _, key := range []string{"ociVersionMin", "ociVersionMax", "hooks", "mountOptions", "freebsd"} {
+		assert.Check(t, is.Contains(doc, key))
+	}
+}
@@ -0,0 +1,151 @@
+package oci
+
+import (
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+
+	"go.sbk.wtf/runj/rctl"
+	runjspec "go.sbk.wtf/runj/runtimespec"
+)
+
+// defaultCPUPeriod is the CFS period, in microseconds, that Linux uses when
+// linux.resources.cpu.period is not set
+const defaultCPUPeriod = 100000
+
+var (
+	// supportedResources lists the linux.resources fields translated to
+	// rctl(8) limits
+	supportedResources = []string{"memory", "cpu", "pids", "blockIO"}
+	// supportedMemory lists the linux.resources.memory fields translated
+	supportedMemory = []string{"limit"}
+	// supportedCPU lists the linux.resources.cpu fields translated
+	supportedCPU = []string{"quota", "period"}
+	// supportedPids lists the linux.resources.pids fields translated
+	supportedPids = []string{"limit"}
+	// supportedBlockIO lists the linux.resources.blockIO fields translated
+	supportedBlockIO = []string{"throttleReadBpsDevice", "throttleWriteBpsDevice", "throttleReadIOPSDevice", "throttleWriteIOPSDevice"}
+)
+
+// ResourceLimits returns the rctl(8) limits for a container: the limits
+// translated from linux.resources in the config, overridden for each resource
+// by the limits configured in the resources section of the runj extension.
+// Settings that cannot be translated are reported by Validate.
+func ResourceLimits(spec *runtimespec.Spec, freebsd *runjspec.FreeBSD) []rctl.Limit {
+	var limits []rctl.Limit
+	if spec != nil && spec.Linux != nil {
+		limits = (&Report{}).linuxResources("linux.resources", spec.Linux.Resources, false)
+	}
+	if freebsd == nil || freebsd.Resources == nil {
+		return limits
+	}
+	return overrideLimits(limits, freebsd.Resources.Limits)
+}
+
+// UpdateLimits returns the rctl(8) limits requested by an update: the limits
+// translated from its Linux resource fields, overridden for each resource by
+// its limits.  As with `runc update`, a Linux limit of -1 removes the limit.
+// The report lists the Linux resource fields that cannot be translated.
+func UpdateLimits(u *runjspec.Update) ([]rctl.Limit, *Report) {
+	r := &Report{}
+	if u == nil {
+		return nil, r
+	}
+	limits := r.linuxResources("resources", &u.LinuxResources, true)
+	return overrideLimits(limits, u.Limits), r
+}
+
+// overrideLimits replaces the limit on each resource in limits with the
+// matching limit in overrides, adding the overrides for other resources
+func overrideLimits(limits []rctl.Limit, overrides []runjspec.FreeBSDResourceLimit) []rctl.Limit {
+	for _, l := range overrides {
+		limit := rctl.Limit{
+			Resource: rctl.Resource(l.Resource),
+			Action:   rctl.Action(l.Action),
+			Amount:   l.Amount,
+		}
+		replaced := false
+		for i := range limits {
+			if limits[i].Resource == limit.Resource {
+				limits[i] = limit
+				replaced = true
+			}
+		}
+		if !replaced {
+			limits = append(limits, limit)
+		}
+	}
+	return limits
+}
+
+// linuxResources translates Linux resources into rctl(8) limits and reports
+// the settings that have no rctl(8) equivalent under path.  Unlimited values
+// (0 or negative) are skipped, except that -1 is translated into an unlimited
+// limit, which removes the current limit, when update is set.  Linux throttles
+// block I/O per device while rctl(8) limits the jail as a whole, so the lowest
+// rate of each throttle is used.
+func (r *Report) linuxResources(path string, res *runtimespec.LinuxResources, update bool) []rctl.Limit {
+	if res == nil {
+		return nil
+	}
+	r.unsupportedFields(path, res, supportedResources...)
+
+	var limits []rctl.Limit
+	if m := res.Memory; m != nil {
+		r.unsupportedFields(path+".memory", m, supportedMemory...)
+		if m.Limit != nil && *m.Limit > 0 {
+			limits = append(limits, rctl.Limit{Resource: rctl.MemoryUse, Amount: uint64(*m.Limit)})
+		} else if update && m.Limit != nil && *m.Limit == -1 {
+			limits = append(limits, rctl.Limit{Resource: rctl.MemoryUse, Unlimited: true})
+		}
+	}
+	if c := res.CPU; c != nil {
+		r.unsupportedFields(path+".cpu", c, supportedCPU...)
+		if c.Quota != nil && *c.Quota > 0 {
+			period := uint64(defaultCPUPeriod)
+			if c.Period != nil && *c.Period > 0 {
+				period = *c.Period
+			}
+			// pcpu is in percent of a single CPU; round up so that a small
+			// quota does not become a limit of 0
+			pcpu := (uint64(*c.Quota)*100 + period - 1) / period
+			limits = append(limits, rctl.Limit{Resource: rctl.PCPU, Amount: pcpu})
+		} else if update && c.Quota != nil && *c.Quota == -1 {
+			limits = append(limits, rctl.Limit{Resource: rctl.PCPU, Unlimited: true})
+		}
+	}
+	if p := res.Pids; p != nil {
+		r.unsupportedFields(path+".pids", p, supportedPids...)
+		if p.Limit != nil && *p.Limit > 0 {
+			limits = append(limits, rctl.Limit{Resource: rctl.MaxProc, Amount: uint64(*p.Limit)})
+		} else if update && p.Limit != nil && *p.Limit == -1 {
+			limits = append(limits, rctl.Limit{Resource: rctl.MaxProc, Unlimited: true})
+		}
+	}
+	if b := res.BlockIO; b != nil {
+		r.unsupportedFields(path+".blockIO", b, supportedBlockIO...)
+		for _, throttle := range []struct {
+			resource rctl.Resource
+			devices  []runtimespec.LinuxThrottleDevice
+		}{
+			{rctl.ReadBPS, b.ThrottleReadBpsDevice},
+			{rctl.WriteBPS, b.ThrottleWriteBpsDevice},
+			{rctl.ReadIOPS, b.ThrottleReadIOPSDevice},
+			{rctl.WriteIOPS, b.ThrottleWriteIOPSDevice},
+		} {
+			if rate, ok := lowestRate(throttle.devices); ok {
+				limits = append(limits, rctl.Limit{Resource: throttle.resource, Amount: rate})
+			}
+		}
+	}
+	return limits
+}
+
+// lowestRate returns the lowest non-zero rate of the devices
+func lowestRate(devices []runtimespec.LinuxThrottleDevice) (uint64, bool) {
+	var rate uint64
+	for _, d := range devices {
+		if d.Rate > 0 && (rate == 0 || d.Rate < rate) {
+			rate = d.Rate
+		}
+	}
+	return rate, rate > 0
+}
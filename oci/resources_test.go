@@ -0,0 +1,146 @@
+package oci
+
+import (
+	"encoding/json"
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+
+	"go.sbk.wtf/runj/rctl"
+	runjspec "go.sbk.wtf/runj/runtimespec"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestResourceLimits(t *testing.T) {
+	assert.Assert(t, ResourceLimits(nil, nil) == nil)
+	assert.Assert(t, ResourceLimits(&runtimespec.Spec{}, &runjspec.FreeBSD{}) == nil)
+
+	spec := &runtimespec.Spec{
+		Linux: &runtimespec.Linux{
+			Resources: &runtimespec.LinuxResources{
+				Memory: &runtimespec.LinuxMemory{Limit: ptr(int64(1 << 30))},
+				Pids:   &runtimespec.LinuxPids{Limit: ptr(int64(100))},
+			},
+		},
+	}
+	limits := ResourceLimits(spec, &runjspec.FreeBSD{
+		Resources: &runjspec.FreeBSDResources{
+			Limits: []runjspec.FreeBSDResourceLimit{
+				{Resource: "maxproc", Amount: 50},
+				{Resource: "readbps", Action: "throttle", Amount: 1 << 20},
+			},
+		},
+	})
+	assert.DeepEqual(t, limits, []rctl.Limit{
+		{Resource: rctl.MemoryUse, Amount: 1 << 30},
+		{Resource: rctl.MaxProc, Amount: 50},
+		{Resource: rctl.ReadBPS, Action: rctl.Throttle, Amount: 1 << 20},
+	})
+}
+
+func TestLinuxResources(t *testing.T) {
+	tests := []struct {
+		name        string
+		resources   *runtimespec.LinuxResources
+		limits      []rctl.Limit
+		unsupported []string
+	}{{
+		name: "nil",
+	}, {
+		name: "mapped",
+		resources: &runtimespec.LinuxResources{
+			Memory: &runtimespec.LinuxMemory{Limit: ptr(int64(512 << 20))},
+			CPU:    &runtimespec.LinuxCPU{Quota: ptr(int64(150000)), Period: ptr(uint64(100000))},
+			Pids:   &runtimespec.LinuxPids{Limit: ptr(int64(64))},
+			BlockIO: &runtimespec.LinuxBlockIO{
+				ThrottleReadBpsDevice:   []runtimespec.LinuxThrottleDevice{{Rate: 2 << 20}, {Rate: 1 << 20}},
+				ThrottleWriteBpsDevice:  []runtimespec.LinuxThrottleDevice{{Rate: 1 << 20}},
+				ThrottleWriteIOPSDevice: []runtimespec.LinuxThrottleDevice{{Rate: 100}},
+			},
+		},
+		limits: []rctl.Limit{
+			{Resource: rctl.MemoryUse, Amount: 512 << 20},
+			{Resource: rctl.PCPU, Amount: 150},
+			{Resource: rctl.MaxProc, Amount: 64},
+			{Resource: rctl.ReadBPS, Amount: 1 << 20},
+			{Resource: rctl.WriteBPS, Amount: 1 << 20},
+			{Resource: rctl.WriteIOPS, Amount: 100},
+		},
+	}, {
+		name: "default period rounds up",
+		resources: &runtimespec.LinuxResources{
+			CPU: &runtimespec.LinuxCPU{Quota: ptr(int64(1000))},
+		},
+		limits: []rctl.Limit{{Resource: rctl.PCPU, Amount: 1}},
+	}, {
+		name: "unlimited",
+		resources: &runtimespec.LinuxResources{
+			Memory: &runtimespec.LinuxMemory{Limit: ptr(int64(-1))},
+			CPU:    &runtimespec.LinuxCPU{Quota: ptr(int64(-1))},
+			Pids:   &runtimespec.LinuxPids{Limit: ptr(int64(0))},
+		},
+	}, {
+		name: "unsupported",
+		resources: &runtimespec.LinuxResources{
+			Devices: []runtimespec.LinuxDeviceCgroup{{Allow: false, Access: "rwm"}},
+			Memory:  &runtimespec.LinuxMemory{Limit: ptr(int64(1 << 30)), Swap: ptr(int64(2 << 30))},
+			CPU:     &runtimespec.LinuxCPU{Shares: ptr(uint64(1024)), Cpus: "0-1"},
+			BlockIO: &runtimespec.LinuxBlockIO{Weight: ptr(uint16(500))},
+			Unified: map[string]string{"memory.high": "1G"},
+		},
+		limits: []rctl.Limit{{Resource: rctl.MemoryUse, Amount: 1 << 30}},
+		unsupported: []string{
+			"linux.resources.devices: not supported",
+			"linux.resources.unified: not supported",
+			"linux.resources.memory.swap: not supported",
+			"linux.resources.cpu.shares: not supported",
+			"linux.resources.cpu.cpus: not supported",
+			"linux.resources.blockIO.weight: not supported",
+		},
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Report{}
+			limits := r.linuxResources("linux.resources", tc.resources, false)
+			assert.DeepEqual(t, limits, tc.limits)
+			assert.DeepEqual(t, r.Unsupported, tc.unsupported)
+		})
+	}
+}
+
+func TestUpdateLimits(t *testing.T) {
+	// the Linux resource fields are at the top level, as for `runc update`
+	data := []byte(`{
+		"memory": {"limit": 2147483648, "swap": 4294967296},
+		"pids": {"limit": 200},
+		"limits": [{"resource": "maxproc", "amount": 100}, {"resource": "nthr", "amount": 1000}]
+	}`)
+	u := &runjspec.Update{}
+	assert.NilError(t, json.Unmarshal(data, u))
+
+	limits, r := UpdateLimits(u)
+	assert.DeepEqual(t, limits, []rctl.Limit{
+		{Resource: rctl.MemoryUse, Amount: 2 << 30},
+		{Resource: rctl.MaxProc, Amount: 100},
+		{Resource: rctl.NThr, Amount: 1000},
+	})
+	assert.DeepEqual(t, r.Unsupported, []string{"resources.memory.swap: not supported"})
+
+	// -1 removes a limit, as with `runc update`
+	u = &runjspec.Update{}
+	assert.NilError(t, json.Unmarshal([]byte(`{"memory": {"limit": -1}, "cpu": {"quota": -1}, "pids": {"limit": 0}}`), u))
+	limits, r = UpdateLimits(u)
+	assert.DeepEqual(t, limits, []rctl.Limit{
+		{Resource: rctl.MemoryUse, Unlimited: true},
+		{Resource: rctl.PCPU, Unlimited: true},
+	})
+	assert.NilError(t, r.Err())
+
+	limits, r = UpdateLimits(nil)
+	assert.Assert(t, limits == nil)
+	assert.NilError(t, r.Err())
+}
@@ -0,0 +1,219 @@
+package oci
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+
+	"go.sbk.wtf/runj/state"
+)
+
+// PermissiveAnnotation is the annotation that, when set to "true" in a
+// config, makes `runj create` warn about unsupported settings instead of
+// failing
+const PermissiveAnnotation = "runj.permissive"
+
+// Report lists the settings in a config that runj cannot apply.  The OCI
+// runtime specification requires a runtime to generate an error when it cannot
+// apply a configured property, so an empty report is the only one for which a
+// container may be created without running in permissive mode.
+type Report struct {
+	// Unsupported holds a description of each unsupported setting, starting
+	// with the setting's path in the config (e.g., "linux.namespaces")
+	Unsupported []string
+}
+
+// Err returns an error listing the unsupported settings, or nil if there are
+// none
+func (r *Report) Err() error {
+	if r == nil || len(r.Unsupported) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unsupported configuration: %s", strings.Join(r.Unsupported, "; "))
+}
+
+func (r *Report) add(format string, args ...any) {
+	r.Unsupported = append(r.Unsupported, fmt.Sprintf(format, args...))
+}
+
+// Permissive reports whether the config asks for unsupported settings to be
+// treated as warnings through PermissiveAnnotation
+func Permissive(spec *runtimespec.Spec) bool {
+	if spec == nil {
+		return false
+	}
+	permissive, _ := strconv.ParseBool(spec.Annotations[PermissiveAnnotation])
+	return permissive
+}
+
+// Validate walks a config loaded with LoadConfig for the given container and
+// reports every setting that runj cannot apply.  The config file stored in the
+// state directory is read again to find hook types that are unknown to
+// runtimespec.Spec, as those are discarded when the config is loaded.
+func Validate(id string, spec *runtimespec.Spec) (*Report, error) {
+	data, err := os.ReadFile(filepath.Join(state.Dir(id), ConfigFileName))
+	if err != nil {
+		return nil, err
+	}
+	r := validateSpec(spec)
+	if err := r.unknownHooks(data); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+var (
+	// supportedProcess lists the process fields runj applies
+	supportedProcess = []string{"terminal", "consoleSize", "user", "args", "env", "cwd", "rlimits"}
+	// supportedHooks lists the hook types runj runs
+	supportedHooks = []string{"prestart", "createRuntime", "createContainer", "startContainer", "poststart", "poststop"}
+	// knownHooks lists every hook type defined by the specification
+	knownHooks = []string{"prestart", "createRuntime", "createContainer", "startContainer", "poststart", "poststop"}
+	// supportedFreeBSD lists the freebsd fields runj applies
+	supportedFreeBSD = []string{"devices", "jail"}
+	// supportedJail lists the freebsd.jail fields runj applies
+	supportedJail = []string{"parent", "host", "ip4", "ip4Addr", "ip6", "ip6Addr", "vnet", "vnetInterfaces", "interface", "sysvmsg", "sysvsem", "sysvshm", "enforceStatfs", "allow"}
+	// supportedMount lists the mount fields runj applies
+	supportedMount = []string{"destination", "type", "source", "options"}
+)
+
+// mountOptions lists the options accepted by mount(8) for any filesystem
+var mountOptions = map[string]bool{
+	"acls":        true,
+	"async":       true,
+	"multilabel":  true,
+	"nfsv4acls":   true,
+	"noatime":     true,
+	"noclusterr":  true,
+	"noclusterw":  true,
+	"noexec":      true,
+	"nosuid":      true,
+	"nosymfollow": true,
+	"rdonly":      true,
+	"ro":          true,
+	"rw":          true,
+	"suiddir":     true,
+	"sync":        true,
+	"union":       true,
+}
+
+// typedMountOptions lists the filesystem-specific options, by filesystem type.
+// Options taking a value are listed by the name before the "=".
+var typedMountOptions = map[string]map[string]bool{
+	"devfs":   {"ruleset": true},
+	"fdescfs": {"linrdlnk": true, "nodup": true},
+	"nullfs":  {"cache": true, "nocache": true},
+	"tmpfs": {
+		"gid":         true,
+		"inodes":      true,
+		"maxfilesize": true,
+		"mode":        true,
+		"size":        true,
+		"uid":         true,
+	},
+}
+
+// validateSpec reports the unsupported settings in spec
+func validateSpec(spec *runtimespec.Spec) *Report {
+	r := &Report{}
+	if spec == nil {
+		return r
+	}
+	r.version(spec.Version)
+	if spec.Process != nil {
+		r.unsupportedFields("process", spec.Process, supportedProcess...)
+	}
+	if spec.Hooks != nil {
+		r.unsupportedFields("hooks", spec.Hooks, supportedHooks...)
+	}
+	for i, m := range spec.Mounts {
+		r.unsupportedFields(fmt.Sprintf("mounts[%d]", i), &m, supportedMount...)
+		r.mountOptions(i, m)
+	}
+	if spec.FreeBSD != nil {
+		r.unsupportedFields("freebsd", spec.FreeBSD, supportedFreeBSD...)
+		if spec.FreeBSD.Jail != nil {
+			r.unsupportedFields("freebsd.jail", spec.FreeBSD.Jail, supportedJail...)
+		}
+	}
+	// linux.resources is translated to rctl(8) limits
+	r.unsupportedFields("linux", spec.Linux, "resources")
+	if spec.Linux != nil {
+		r.linuxResources("linux.resources", spec.Linux.Resources, false)
+	}
+	r.unsupportedFields("solaris", spec.Solaris)
+	r.unsupportedFields("windows", spec.Windows)
+	r.unsupportedFields("vm", spec.VM)
+	r.unsupportedFields("zos", spec.ZOS)
+	return r
+}
+
+// version reports an ociVersion whose major version differs from the version
+// of the specification runj implements.  A missing version is not reported.
+func (r *Report) version(version string) {
+	if version == "" {
+		return
+	}
+	major, _, _ := strings.Cut(version, ".")
+	if m, err := strconv.Atoi(major); err != nil || m != runtimespec.VersionMajor {
+		r.add("ociVersion %q: runj implements version %s of the runtime specification", version, runtimespec.Version)
+	}
+}
+
+// mountOptions reports the options of the i'th mount that mount(8) would not
+// accept for the mount's type
+func (r *Report) mountOptions(i int, m runtimespec.Mount) {
+	for _, opt := range m.Options {
+		name, _, _ := strings.Cut(opt, "=")
+		if mountOptions[opt] || typedMountOptions[m.Type][name] {
+			continue
+		}
+		r.add("mounts[%d].options: unknown option %q for type %q", i, opt, m.Type)
+	}
+}
+
+// unknownHooks reports hook types in the raw config that the specification
+// does not define
+func (r *Report) unknownHooks(data []byte) error {
+	var config struct {
+		Hooks map[string]json.RawMessage `json:"hooks"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(config.Hooks)) {
+		if !slices.Contains(knownHooks, name) {
+			r.add("hooks.%s: unknown hook type", name)
+		}
+	}
+	return nil
+}
+
+// unsupportedFields reports each field of the struct v points to that is set
+// and is not named in supported.  Fields are named by their JSON name and
+// prefixed with path.
+func (r *Report) unsupportedFields(path string, v any, supported ...string) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+	}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		name, _, _ := strings.Cut(rt.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || rv.Field(i).IsZero() || slices.Contains(supported, name) {
+			continue
+		}
+		r.add("%s.%s: not supported", path, name)
+	}
+}
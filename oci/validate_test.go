@@ -0,0 +1,113 @@
+package oci
+
+import (
+	"testing"
+
+	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
+	"gotest.tools/v3/assert"
+)
+
+func TestValidateSpecSupported(t *testing.T) {
+	timeout := 1
+	spec := &runtimespec.Spec{
+		Version: runtimespec.Version,
+		Process: &runtimespec.Process{
+			Terminal: true,
+			Args:     []string{"/bin/sh"},
+			Env:      []string{"PATH=/bin"},
+			Cwd:      "/",
+			User:     runtimespec.User{UID: 1000, GID: 1000},
+			Rlimits:  []runtimespec.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 1024, Soft: 1024}},
+		},
+		Root:     &runtimespec.Root{Path: "root", Readonly: true},
+		Hostname: "example",
+		Mounts: []runtimespec.Mount{
+			{Destination: "/dev", Type: "devfs", Source: "devfs", Options: []string{"ruleset=4"}},
+			{Destination: "/tmp", Type: "tmpfs", Source: "tmpfs", Options: []string{"nosuid", "mode=1777", "size=64m"}},
+			{Destination: "/volume", Type: "nullfs", Source: "/volume", Options: []string{"ro"}},
+		},
+		Hooks: &runtimespec.Hooks{
+			CreateRuntime: []runtimespec.Hook{{Path: "/bin/true", Timeout: &timeout}},
+			Poststop:      []runtimespec.Hook{{Path: "/bin/true"}},
+		},
+		FreeBSD: &runtimespec.FreeBSD{
+			Jail: &runtimespec.FreeBSDJail{
+				Ip4:     runtimespec.FreeBSDShareNew,
+				Ip4Addr: []string{"127.0.0.2"},
+			},
+		},
+		Annotations: map[string]string{"example": "value"},
+	}
+	r := validateSpec(spec)
+	assert.NilError(t, r.Err())
+	assert.Equal(t, len(r.Unsupported), 0)
+}
+
+func TestValidateSpecUnsupported(t *testing.T) {
+	oomScoreAdj := 100
+	spec := &runtimespec.Spec{
+		Process: &runtimespec.Process{
+			NoNewPrivileges: true,
+			OOMScoreAdj:     &oomScoreAdj,
+		},
+		Mounts: []runtimespec.Mount{
+			{Destination: "/proc", Type: "proc", Source: "proc", Options: []string{"nosuid", "nodev"}},
+			{Destination: "/dev", Type: "devfs", Source: "devfs", Options: []string{"size=1"}},
+		},
+		Linux: &runtimespec.Linux{
+			Namespaces:  []runtimespec.LinuxNamespace{{Type: runtimespec.PIDNamespace}},
+			CgroupsPath: "/default/example",
+		},
+	}
+	r := validateSpec(spec)
+	assert.DeepEqual(t, r.Unsupported, []string{
+		"process.noNewPrivileges: not supported",
+		"process.oomScoreAdj: not supported",
+		`mounts[0].options: unknown option "nodev" for type "proc"`,
+		`mounts[1].options: unknown option "size=1" for type "devfs"`,
+		"linux.cgroupsPath: not supported",
+		"linux.namespaces: not supported",
+	})
+	assert.ErrorContains(t, r.Err(), "unsupported configuration: process.noNewPrivileges: not supported; ")
+}
+
+func TestValidateVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		ok      bool
+	}{
+		{version: "", ok: true},
+		{version: "1.0.0", ok: true},
+		{version: "1.3.0-rc.1", ok: true},
+		{version: "0.2.0", ok: false},
+		{version: "2.0.0", ok: false},
+		{version: "latest", ok: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			r := validateSpec(&runtimespec.Spec{Version: tc.version})
+			if tc.ok {
+				assert.NilError(t, r.Err())
+			} else {
+				assert.ErrorContains(t, r.Err(), "ociVersion")
+			}
+		})
+	}
+}
+
+func TestValidateUnknownHooks(t *testing.T) {
+	r := &Report{}
+	err := r.unknownHooks([]byte(`{"hooks": {"poststop": [], "preStop": [], "createRuntime": [], "afterStart": []}}`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, r.Unsupported, []string{
+		"hooks.afterStart: unknown hook type",
+		"hooks.preStop: unknown hook type",
+	})
+}
+
+func TestPermissive(t *testing.T) {
+	assert.Assert(t, !Permissive(nil))
+	assert.Assert(t, !Permissive(&runtimespec.Spec{}))
+	assert.Assert(t, !Permissive(&runtimespec.Spec{Annotations: map[string]string{PermissiveAnnotation: "no"}}))
+	assert.Assert(t, Permissive(&runtimespec.Spec{Annotations: map[string]string{PermissiveAnnotation: "true"}}))
+}
@@ -0,0 +1,235 @@
+// Package rctl builds and applies rctl(8) rules that limit the resources
+// available to a jail.  Building rules does not depend on the FreeBSD kernel;
+// applying them requires racct to be enabled (kern.racct.enable=1).
+package rctl
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const rctlCmd = "/usr/bin/rctl"
+
+// Resource is a resource that can be limited, as named in rctl(8)
+type Resource string
+
+const (
+	// MemoryUse is the resident set size, in bytes
+	MemoryUse Resource = "memoryuse"
+	// VMemoryUse is the address space limit, in bytes
+	VMemoryUse Resource = "vmemoryuse"
+	// SwapUse is the swap space that may be reserved, in bytes
+	SwapUse Resource = "swapuse"
+	// MaxProc is the number of processes
+	MaxProc Resource = "maxproc"
+	// NThr is the number of threads
+	NThr Resource = "nthr"
+	// OpenFiles is the number of file descriptors
+	OpenFiles Resource = "openfiles"
+	// PCPU is the CPU usage, in percent of a single CPU
+	PCPU Resource = "pcpu"
+	// ReadBPS is the filesystem read rate, in bytes per second
+	ReadBPS Resource = "readbps"
+	// WriteBPS is the filesystem write rate, in bytes per second
+	WriteBPS Resource = "writebps"
+	// ReadIOPS is the filesystem read rate, in operations per second
+	ReadIOPS Resource = "readiops"
+	// WriteIOPS is the filesystem write rate, in operations per second
+	WriteIOPS Resource = "writeiops"
+)
+
+// Action is what the kernel does when a limit is exceeded
+type Action string
+
+const (
+	// Deny denies the allocation that would exceed the limit
+	Deny Action = "deny"
+	// Throttle slows down the process; it is only available for the I/O
+	// resources
+	Throttle Action = "throttle"
+)
+
+// resources lists the supported resources and whether each is an I/O
+// resource, which can only be throttled
+var resources = map[Resource]bool{
+	MemoryUse:  false,
+	VMemoryUse: false,
+	SwapUse:    false,
+	MaxProc:    false,
+	NThr:       false,
+	OpenFiles:  false,
+	PCPU:       false,
+	ReadBPS:    true,
+	WriteBPS:   true,
+	ReadIOPS:   true,
+	WriteIOPS:  true,
+}
+
+// Resources returns the supported resources, sorted by name
+func Resources() []Resource {
+	return slices.Sorted(maps.Keys(resources))
+}
+
+// Limit is a limit on a resource of a jail
+type Limit struct {
+	Resource Resource
+	// Action defaults to Throttle for the I/O resources and Deny for the
+	// others
+	Action Action
+	Amount uint64
+	// Unlimited removes the limit on the resource instead; it is only
+	// meaningful for Update
+	Unlimited bool
+}
+
+// Subject returns the rctl(8) subject for the named jail
+func Subject(jail string) string {
+	return "jail:" + jail
+}
+
+// Rules returns the rctl(8) rules ("jail:<name>:<resource>:<action>=<amount>")
+// that apply the limits to the named jail.  An unlimited limit is returned as
+// the rule's filter, without an amount, which Update treats as a removal.  An
+// error is returned for an unknown resource, an action the resource does not
+// support, or a repeated limit.
+func Rules(jail string, limits []Limit) ([]string, error) {
+	rules := make([]string, 0, len(limits))
+	seen := make(map[string]bool)
+	for _, l := range limits {
+		io, ok := resources[l.Resource]
+		if !ok {
+			return nil, fmt.Errorf("rctl: unknown resource %q", l.Resource)
+		}
+		action := l.Action
+		if action == "" {
+			action = Deny
+			if io {
+				action = Throttle
+			}
+		}
+		switch {
+		case action == Throttle && !io:
+			return nil, fmt.Errorf("rctl: %s: %s is only supported for I/O resources", l.Resource, action)
+		case action == Deny && io:
+			return nil, fmt.Errorf("rctl: %s: %s is not supported for I/O resources", l.Resource, action)
+		case action != Deny && action != Throttle:
+			return nil, fmt.Errorf("rctl: %s: unknown action %q", l.Resource, action)
+		}
+		filter := Subject(jail) + ":" + string(l.Resource) + ":" + string(action)
+		if seen[filter] {
+			return nil, fmt.Errorf("rctl: duplicate limit %s:%s", l.Resource, action)
+		}
+		seen[filter] = true
+		if l.Unlimited {
+			rules = append(rules, filter)
+			continue
+		}
+		rules = append(rules, filter+"="+strconv.FormatUint(l.Amount, 10))
+	}
+	return rules, nil
+}
+
+// filter returns the rctl(8) filter matching exactly the rule, which is the
+// rule without its amount
+func filter(rule string) string {
+	f, _, _ := strings.Cut(rule, "=")
+	return f
+}
+
+// Add adds the rules with rctl(8)
+func Add(ctx context.Context, rules []string) error {
+	if len(rules) == 0 {
+		return nil
+	}
+	return run(ctx, append([]string{"-a"}, rules...)...)
+}
+
+// Remove removes rules added with Add.  Every rule is attempted; the first
+// error is returned.
+func Remove(ctx context.Context, rules []string) error {
+	var firstErr error
+	for _, rule := range rules {
+		if err := run(ctx, "-r", filter(rule)); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Update applies updates to the rules added with Add.  rctl(8) only replaces
+// the amount of an existing deny rule in place, so a deny update is added
+// directly and the resource is never left without a limit.  Adding a rule with
+// any other action keeps the current rule in force beside it, so the current
+// rule with the same subject, resource, and action is removed first.  An update
+// without an amount (see Rules) removes the current rule.  The resulting rules
+// are returned, also when an error occurs, so that they can be removed later.
+func Update(ctx context.Context, current []string, updates []string) ([]string, error) {
+	rules := slices.Clone(current)
+	for _, update := range updates {
+		matches := func(rule string) bool { return filter(rule) == filter(update) }
+		unlimited := filter(update) == update
+		if slices.ContainsFunc(rules, matches) && (unlimited || !strings.HasSuffix(filter(update), ":"+string(Deny))) {
+			if err := run(ctx, "-r", filter(update)); err != nil {
+				return rules, err
+			}
+			rules = slices.DeleteFunc(rules, matches)
+		}
+		if unlimited {
+			continue
+		}
+		if err := Add(ctx, []string{update}); err != nil {
+			return rules, err
+		}
+		rules = append(slices.DeleteFunc(rules, matches), update)
+	}
+	return rules, nil
+}
+
+// Usage returns the resource usage of a jail reported by `rctl -u`, by
+// resource name.  It includes resources that cannot be limited with Rules,
+// such as "cputime".
+func Usage(ctx context.Context, jail string) (map[string]uint64, error) {
+	args := []string{"-u", Subject(jail)}
+	cmd := exec.CommandContext(ctx, rctlCmd, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("rctl: %s: %q: %w", strings.Join(args, " "), stderr.String(), err)
+	}
+	return parseUsage(string(out))
+}
+
+// parseUsage parses the "resource=amount" lines printed by `rctl -u`
+func parseUsage(out string) (map[string]uint64, error) {
+	usage := make(map[string]uint64)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		resource, amount, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("rctl: invalid usage line %q", line)
+		}
+		n, err := strconv.ParseUint(amount, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("rctl: invalid usage of %s: %w", resource, err)
+		}
+		usage[resource] = n
+	}
+	return usage, nil
+}
+
+func run(ctx context.Context, args ...string) error {
+	out, err := exec.CommandContext(ctx, rctlCmd, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("rctl: %s: %q: %w", strings.Join(args, " "), out, err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package rctl
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		jail   string
+		limits []Limit
+		rules  []string
+		err    error
+	}{{
+		name:  "empty",
+		jail:  "empty",
+		rules: []string{},
+	}, {
+		name: "defaults",
+		jail: "defaults",
+		limits: []Limit{
+			{Resource: MemoryUse, Amount: 1 << 30},
+			{Resource: MaxProc, Amount: 100},
+			{Resource: PCPU, Amount: 50},
+			{Resource: OpenFiles, Amount: 1024},
+			{Resource: ReadBPS, Amount: 1 << 20},
+			{Resource: WriteBPS, Amount: 1 << 20},
+		},
+		rules: []string{
+			"jail:defaults:memoryuse:deny=1073741824",
+			"jail:defaults:maxproc:deny=100",
+			"jail:defaults:pcpu:deny=50",
+			"jail:defaults:openfiles:deny=1024",
+			"jail:defaults:readbps:throttle=1048576",
+			"jail:defaults:writebps:throttle=1048576",
+		},
+	}, {
+		name:   "explicit action",
+		jail:   "parent.child",
+		limits: []Limit{{Resource: WriteIOPS, Action: Throttle, Amount: 10}},
+		rules:  []string{"jail:parent.child:writeiops:throttle=10"},
+	}, {
+		name: "unlimited",
+		jail: "unlimited",
+		limits: []Limit{
+			{Resource: MemoryUse, Unlimited: true},
+			{Resource: ReadBPS, Unlimited: true},
+		},
+		rules: []string{
+			"jail:unlimited:memoryuse:deny",
+			"jail:unlimited:readbps:throttle",
+		},
+	}, {
+		name:   "unknown resource",
+		jail:   "invalid",
+		limits: []Limit{{Resource: "cputime", Amount: 10}},
+		err:    errors.New(`rctl: unknown resource "cputime"`),
+	}, {
+		name:   "throttle memory",
+		jail:   "invalid",
+		limits: []Limit{{Resource: MemoryUse, Action: Throttle, Amount: 10}},
+		err:    errors.New("rctl: memoryuse: throttle is only supported for I/O resources"),
+	}, {
+		name:   "deny io",
+		jail:   "invalid",
+		limits: []Limit{{Resource: ReadBPS, Action: Deny, Amount: 10}},
+		err:    errors.New("rctl: readbps: deny is not supported for I/O resources"),
+	}, {
+		name:   "unknown action",
+		jail:   "invalid",
+		limits: []Limit{{Resource: MaxProc, Action: "log", Amount: 10}},
+		err:    errors.New(`rctl: maxproc: unknown action "log"`),
+	}, {
+		name: "duplicate",
+		jail: "invalid",
+		limits: []Limit{
+			{Resource: MaxProc, Amount: 10},
+			{Resource: MaxProc, Action: Deny, Amount: 20},
+		},
+		err: errors.New("rctl: duplicate limit maxproc:deny"),
+	}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rules, err := Rules(tc.jail, tc.limits)
+			if tc.err != nil {
+				assert.EqualError(t, err, tc.err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.rules, rules)
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	assert.Equal(t, "jail:a.b:memoryuse:deny", filter("jail:a.b:memoryuse:deny=1073741824"))
+	assert.Equal(t, "jail:a:maxproc:deny", filter("jail:a:maxproc:deny"))
+}
+
+func TestParseUsage(t *testing.T) {
+	usage, err := parseUsage("cputime=3\ndatasize=4096\nmemoryuse=1789952\nmaxproc=2\nopenfiles=11\npcpu=0\nreadbps=0\nwritebps=512\n")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{
+		"cputime":   3,
+		"datasize":  4096,
+		"memoryuse": 1789952,
+		"maxproc":   2,
+		"openfiles": 11,
+		"pcpu":      0,
+		"readbps":   0,
+		"writebps":  512,
+	}, usage)
+
+	_, err = parseUsage("memoryuse\n")
+	assert.EqualError(t, err, `rctl: invalid usage line "memoryuse"`)
+	_, err = parseUsage("memoryuse=1M\n")
+	assert.ErrorContains(t, err, "rctl: invalid usage of memoryuse")
+}
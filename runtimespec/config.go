@@ -10,9 +10,17 @@ Copyright 2020 Samuel Karp.
 
 package runtimespec
 
+import (
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
 // FreeBSD specifies FreeBSD-specific configuration options
 type FreeBSD struct {
 	Network *FreeBSDNetwork `json:"network,omitempty"`
+	Allow   *FreeBSDAllow   `json:"allow,omitempty"`
+	IPC     *FreeBSDIPC     `json:"ipc,omitempty"`
+	// Resources limits the resources available to the jail with rctl(8)
+	Resources *FreeBSDResources `json:"resources,omitempty"`
 }
 
 // FreeBSDNetwork specifies how the jail's network should be configured by the
@@ -61,3 +69,105 @@ const (
 )
 
 type FreeBSDVNetMode string
+
+// FreeBSDAllow specifies the privileges granted to processes in the jail.  Each
+// field corresponds to an allow.* parameter described in the jail(8) manual
+// page.  A false value leaves the kernel default.
+type FreeBSDAllow struct {
+	// SetHostname allows the jail's hostname to be changed (allow.set_hostname)
+	SetHostname bool `json:"setHostname,omitempty"`
+	// RawSockets allows raw sockets to be created (allow.raw_sockets)
+	RawSockets bool `json:"rawSockets,omitempty"`
+	// Chflags allows system file flags to be changed (allow.chflags)
+	Chflags bool `json:"chflags,omitempty"`
+	// Mount lists the file system types that may be mounted in the jail
+	// (allow.mount and allow.mount.<type>)
+	Mount []string `json:"mount,omitempty"`
+	// Quotas allows file system quotas to be administered (allow.quotas)
+	Quotas bool `json:"quotas,omitempty"`
+	// SocketAf allows sockets of any address family (allow.socket_af)
+	SocketAf bool `json:"socketAf,omitempty"`
+	// Mlock allows memory to be locked (allow.mlock)
+	Mlock bool `json:"mlock,omitempty"`
+	// ReservedPorts allows binding to ports below 1024 (allow.reserved_ports)
+	ReservedPorts bool `json:"reservedPorts,omitempty"`
+	// Suser allows the jail's root user to be privileged (allow.suser)
+	Suser bool `json:"suser,omitempty"`
+}
+
+// AllowMountTypes lists the file system types that can be named in
+// FreeBSDAllow.Mount; each has an allow.mount.<type> parameter
+var AllowMountTypes = []string{
+	"devfs",
+	"fdescfs",
+	"fusefs",
+	"linprocfs",
+	"linsysfs",
+	"nullfs",
+	"procfs",
+	"tmpfs",
+	"zfs",
+}
+
+// FreeBSDIPC specifies how the jail's System V IPC objects are shared
+type FreeBSDIPC struct {
+	// SysVMsg is the mode of System V message queues (sysvmsg)
+	SysVMsg FreeBSDSysVMode `json:"sysvmsg,omitempty"`
+	// SysVSem is the mode of System V semaphores (sysvsem)
+	SysVSem FreeBSDSysVMode `json:"sysvsem,omitempty"`
+	// SysVShm is the mode of System V shared memory segments (sysvshm)
+	SysVShm FreeBSDSysVMode `json:"sysvshm,omitempty"`
+}
+
+// FreeBSDSysVMode describes the mode of a System V IPC primitive in the jail.
+// Possible values are "new" (the jail has its own objects), "inherit" (the
+// jail shares the objects of its parent), and "disable".
+type FreeBSDSysVMode string
+
+const (
+	FreeBSDSysVModeNew     FreeBSDSysVMode = "new"
+	FreeBSDSysVModeInherit FreeBSDSysVMode = "inherit"
+	FreeBSDSysVModeDisable FreeBSDSysVMode = "disable"
+)
+
+// FreeBSDResources specifies the rctl(8) limits applied to the jail
+type FreeBSDResources struct {
+	Limits []FreeBSDResourceLimit `json:"limits,omitempty"`
+}
+
+// FreeBSDResourceLimit is a limit on a resource of the jail.  It is applied as
+// the rctl(8) rule "jail:<name>:<resource>:<action>=<amount>".
+type FreeBSDResourceLimit struct {
+	// Resource is the resource to limit, as named in rctl(8): "memoryuse",
+	// "vmemoryuse", "swapuse", "maxproc", "nthr", "openfiles", "pcpu",
+	// "readbps", "writebps", "readiops", or "writeiops"
+	Resource string `json:"resource"`
+	// Action is "deny" or "throttle".  It defaults to "throttle" for the I/O
+	// resources, which cannot be denied, and to "deny" for the others.
+	Action string `json:"action,omitempty"`
+	// Amount is the limit, in the unit rctl(8) uses for the resource
+	Amount uint64 `json:"amount"`
+}
+
+// Update is the format of the file read by `runj update --resources`.  The
+// Linux resource fields are at the top level, as in the file read by `runc
+// update --resources`, so that the same file can be used with either runtime;
+// they are translated to rctl(8) limits.
+type Update struct {
+	specs.LinuxResources
+	// Limits are rctl(8) limits, in the format of the resources section of
+	// runj.ext.json.  They replace translated limits for the same resource.
+	Limits []FreeBSDResourceLimit `json:"limits,omitempty"`
+	// Jail holds the jail parameters to change
+	Jail *FreeBSDJailUpdate `json:"jail,omitempty"`
+}
+
+// FreeBSDJailUpdate holds the jail parameters that can be changed while the
+// jail exists
+type FreeBSDJailUpdate struct {
+	// EnforceStatfs is the new mount visibility (see jail(8) for details)
+	EnforceStatfs *int `json:"enforceStatfs,omitempty"`
+	// Allow grants additional privileges; privileges already granted are not
+	// revoked
+	Allow *FreeBSDAllow `json:"allow,omitempty"`
+}
@@ -1,23 +1,50 @@
 package state
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 )
 
-const defaultStateDir = "/var/lib/runj/jails"
+// DefaultRoot is the directory under which per-container state is stored
+// unless another root is set with SetRoot
+const DefaultRoot = "/var/lib/runj/jails"
 
-// stateDir is the directory under which per-container state is stored.  It is a
-// variable rather than a constant so that tests can redirect it to a temporary
-// location.
-var stateDir = defaultStateDir
+// stateDir is the directory under which per-container state is stored.  It is
+// changed by SetRoot, and tests redirect it to a temporary location.
+var stateDir = DefaultRoot
+
+// SetRoot changes the directory under which per-container state is stored.  A
+// relative path is made absolute, as the paths of files in the state directory
+// are passed to runj-entrypoint and used from other working directories.
+func SetRoot(dir string) error {
+	if dir == "" {
+		return errors.New("state: root must not be empty")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("state: invalid root %q: %w", dir, err)
+	}
+	stateDir = abs
+	return nil
+}
+
+// Root returns the directory under which per-container state is stored
+func Root() string {
+	return stateDir
+}
 
 // Create creates a state file for runj
 func Create(id, bundle string) (*State, error) {
 	s := &State{
-		ID:     id,
-		Bundle: bundle,
-		Status: StatusCreating,
+		ID:      id,
+		Bundle:  bundle,
+		Status:  StatusCreating,
+		Created: time.Now().UTC(),
 	}
 	err := os.MkdirAll(Dir(id), 0755)
 	if err != nil {
@@ -35,6 +62,28 @@ func Dir(id string) string {
 	return filepath.Join(stateDir, id)
 }
 
+// List returns the IDs of the containers with stored state, sorted
+func List() ([]string, error) {
+	entries, err := os.ReadDir(stateDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(Dir(e.Name()), stateFile)); err != nil {
+			continue
+		}
+		ids = append(ids, e.Name())
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
 // Remove removes the state for a container
 func Remove(id string) error {
 	return os.RemoveAll(Dir(id))
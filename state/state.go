@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 )
@@ -20,6 +21,9 @@ const (
 	StatusCreated Status = "created"
 	// StatusRunning represents a running container
 	StatusRunning Status = "running"
+	// StatusPaused represents a running container whose processes have been
+	// stopped with SIGSTOP by `runj pause`
+	StatusPaused Status = "paused"
 	// StatusStopped represents a container that has exited
 	StatusStopped Status = "stopped"
 )
@@ -30,6 +34,9 @@ type State struct {
 	ID string
 	// JID is the jail ID of the jail backing the container
 	JID int
+	// JailName is the name of the jail backing the container when it differs
+	// from ID, as it does for a child of another jail ("parent.id")
+	JailName string `json:",omitempty"`
 	// Status is the status of the container
 	Status Status
 	// Bundle is the directory containing the config and rootfs
@@ -38,6 +45,34 @@ type State struct {
 	PID int
 	// OCIVersion is the OCI runtime spec version the bundle declared
 	OCIVersion string
+	// Created is the time the container was created
+	Created time.Time `json:",omitzero"`
+	// DevfsRuleset is the devfs ruleset created for the container's devices,
+	// or 0 if none was created
+	DevfsRuleset int `json:",omitempty"`
+	// Interface is the host interface the container's addresses were added
+	// to as aliases
+	Interface string `json:",omitempty"`
+	// Aliases are the addresses runj added to Interface, which are removed
+	// when the container is deleted
+	Aliases []string `json:",omitempty"`
+	// RctlRules are the rctl(8) rules that limit the container's resources,
+	// which are removed when the container is deleted
+	RctlRules []string `json:",omitempty"`
+	// ChildrenMax are the changes runj made to the children.max parameter of
+	// the parent jail and its ancestors, which are undone when the container
+	// is deleted
+	ChildrenMax []ChildrenMax `json:",omitempty"`
+}
+
+// ChildrenMax is a change runj made to the children.max parameter of a jail
+type ChildrenMax struct {
+	// Jail is the name of the jail
+	Jail string
+	// Original is the value of children.max before runj raised it
+	Original int
+	// Raised is the value runj set
+	Raised int
 }
 
 // Output is the expected output format for the state command
@@ -80,6 +115,14 @@ func (s *State) Output() Output {
 	}
 }
 
+// Jail returns the name of the jail backing the container
+func (s *State) Jail() string {
+	if s.JailName != "" {
+		return s.JailName
+	}
+	return s.ID
+}
+
 // Load reads the state from disk and parses it
 func Load(id string) (*State, error) {
 	d, err := os.ReadFile(filepath.Join(Dir(id), stateFile))
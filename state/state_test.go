@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/stretchr/testify/assert"
@@ -54,6 +55,7 @@ func TestCreateAndLoad(t *testing.T) {
 	assert.Equal(t, "container1", created.ID)
 	assert.Equal(t, "/bundle", created.Bundle)
 	assert.Equal(t, StatusCreating, created.Status)
+	assert.WithinDuration(t, time.Now(), created.Created, time.Minute)
 
 	loaded, err := Load("container1")
 	require.NoError(t, err)
@@ -90,6 +92,14 @@ func TestSaveRoundTrip(t *testing.T) {
 	assert.Equal(t, StatusRunning, loaded.Status)
 }
 
+func TestJail(t *testing.T) {
+	s := &State{ID: "child"}
+	assert.Equal(t, "child", s.Jail())
+
+	s.JailName = "parent.child"
+	assert.Equal(t, "parent.child", s.Jail())
+}
+
 func TestLoadMissing(t *testing.T) {
 	redirectStateDir(t)
 
@@ -112,7 +122,51 @@ func TestRemove(t *testing.T) {
 	assert.NoError(t, Remove("container1"))
 }
 
+func TestList(t *testing.T) {
+	redirectStateDir(t)
+
+	ids, err := List()
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	for _, id := range []string{"b", "a", "c"} {
+		_, err := Create(id, "/bundle")
+		require.NoError(t, err)
+	}
+	// a directory without a state file is not a container
+	require.NoError(t, os.Mkdir(Dir("partial"), 0755))
+
+	ids, err = List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, ids)
+}
+
+func TestListMissingDir(t *testing.T) {
+	redirectStateDir(t)
+	stateDir = filepath.Join(stateDir, "missing")
+
+	ids, err := List()
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
 func TestDir(t *testing.T) {
 	redirectStateDir(t)
 	assert.Equal(t, filepath.Join(stateDir, "abc"), Dir("abc"))
 }
+
+func TestSetRoot(t *testing.T) {
+	redirectStateDir(t)
+
+	dir := t.TempDir()
+	require.NoError(t, SetRoot(dir))
+	assert.Equal(t, dir, Root())
+	assert.Equal(t, filepath.Join(dir, "abc"), Dir("abc"))
+
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, SetRoot("relative"))
+	assert.Equal(t, filepath.Join(wd, "relative"), Root())
+
+	assert.Error(t, SetRoot(""))
+}
@@ -0,0 +1,110 @@
+// Package stats defines the resource usage statistics runj reports for a
+// container.  Stats is registered with typeurl under TypeURL, so that the
+// metrics returned by the containerd shim can be decoded with
+// typeurl.UnmarshalAny by importing this package.  The Any holds Stats
+// encoded as JSON.
+package stats
+
+import "github.com/containerd/typeurl/v2"
+
+// TypeURL is the type URL of Stats in the metrics returned by the containerd
+// shim
+const TypeURL = "go.sbk.wtf/runj/stats/Stats"
+
+func init() {
+	typeurl.Register(&Stats{}, "go.sbk.wtf/runj/stats", "Stats")
+}
+
+// Stats is the resource usage of a container, as accounted by the kernel's
+// racct(4) framework and reported by `rctl -u`, along with the counters of
+// the network interfaces of a vnet jail
+type Stats struct {
+	CPU       CPU    `json:"cpu"`
+	Memory    Memory `json:"memory"`
+	Pids      Pids   `json:"pids"`
+	OpenFiles uint64 `json:"openFiles"`
+	IO        IO     `json:"io"`
+	// Network holds the counters of each interface in the container's vnet.
+	// It is empty when the container does not have its own vnet.
+	Network []NetworkInterface `json:"network,omitempty"`
+}
+
+// CPU is the CPU usage of a container
+type CPU struct {
+	// Time is the CPU time used, in seconds (cputime)
+	Time uint64 `json:"time"`
+	// Percent is the recent CPU usage, in percent of a single CPU (pcpu)
+	Percent uint64 `json:"percent"`
+}
+
+// Memory is the memory usage of a container, in bytes
+type Memory struct {
+	// Usage is the resident set size (memoryuse)
+	Usage uint64 `json:"usage"`
+	// Virtual is the address space size (vmemoryuse)
+	Virtual uint64 `json:"virtual"`
+	// Swap is the swap space reserved (swapuse)
+	Swap uint64 `json:"swap"`
+	// Locked is the locked memory (memorylocked)
+	Locked uint64 `json:"locked"`
+}
+
+// Pids is the number of processes and threads in a container
+type Pids struct {
+	// Current is the number of processes (maxproc)
+	Current uint64 `json:"current"`
+	// Threads is the number of threads (nthr)
+	Threads uint64 `json:"threads"`
+}
+
+// IO is the recent filesystem I/O rate of a container
+type IO struct {
+	// ReadBPS is the read rate, in bytes per second (readbps)
+	ReadBPS uint64 `json:"readBps"`
+	// WriteBPS is the write rate, in bytes per second (writebps)
+	WriteBPS uint64 `json:"writeBps"`
+	// ReadIOPS is the read rate, in operations per second (readiops)
+	ReadIOPS uint64 `json:"readIops"`
+	// WriteIOPS is the write rate, in operations per second (writeiops)
+	WriteIOPS uint64 `json:"writeIops"`
+}
+
+// NetworkInterface holds the counters of a network interface, as reported by
+// `netstat -i`
+type NetworkInterface struct {
+	Name      string `json:"name"`
+	RxBytes   uint64 `json:"rxBytes"`
+	RxPackets uint64 `json:"rxPackets"`
+	RxErrors  uint64 `json:"rxErrors"`
+	TxBytes   uint64 `json:"txBytes"`
+	TxPackets uint64 `json:"txPackets"`
+	TxErrors  uint64 `json:"txErrors"`
+}
+
+// FromUsage converts the usage reported by `rctl -u`, by resource name, into
+// Stats.  Resources missing from usage are reported as 0.
+func FromUsage(usage map[string]uint64) *Stats {
+	return &Stats{
+		CPU: CPU{
+			Time:    usage["cputime"],
+			Percent: usage["pcpu"],
+		},
+		Memory: Memory{
+			Usage:   usage["memoryuse"],
+			Virtual: usage["vmemoryuse"],
+			Swap:    usage["swapuse"],
+			Locked:  usage["memorylocked"],
+		},
+		Pids: Pids{
+			Current: usage["maxproc"],
+			Threads: usage["nthr"],
+		},
+		OpenFiles: usage["openfiles"],
+		IO: IO{
+			ReadBPS:   usage["readbps"],
+			WriteBPS:  usage["writebps"],
+			ReadIOPS:  usage["readiops"],
+			WriteIOPS: usage["writeiops"],
+		},
+	}
+}
@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/containerd/typeurl/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromUsage(t *testing.T) {
+	s := FromUsage(map[string]uint64{
+		"cputime":      3,
+		"pcpu":         12,
+		"memoryuse":    1789952,
+		"vmemoryuse":   13631488,
+		"swapuse":      4096,
+		"memorylocked": 8192,
+		"maxproc":      2,
+		"nthr":         5,
+		"openfiles":    11,
+		"readbps":      100,
+		"writebps":     200,
+		"readiops":     3,
+		"writeiops":    4,
+		"wallclock":    60,
+	})
+	assert.Equal(t, &Stats{
+		CPU:       CPU{Time: 3, Percent: 12},
+		Memory:    Memory{Usage: 1789952, Virtual: 13631488, Swap: 4096, Locked: 8192},
+		Pids:      Pids{Current: 2, Threads: 5},
+		OpenFiles: 11,
+		IO:        IO{ReadBPS: 100, WriteBPS: 200, ReadIOPS: 3, WriteIOPS: 4},
+	}, s)
+}
+
+func TestFromUsageEmpty(t *testing.T) {
+	assert.Equal(t, &Stats{}, FromUsage(nil))
+}
+
+func TestTypeURL(t *testing.T) {
+	url, err := typeurl.TypeURL(&Stats{})
+	assert.NoError(t, err)
+	assert.Equal(t, TypeURL, url)
+
+	s := &Stats{
+		Memory:  Memory{Usage: 4096},
+		Network: []NetworkInterface{{Name: "epair0b", RxBytes: 100, TxBytes: 200}},
+	}
+	a, err := typeurl.MarshalAny(s)
+	assert.NoError(t, err)
+	assert.Equal(t, TypeURL, a.GetTypeUrl())
+	decoded, err := typeurl.UnmarshalAny(a)
+	assert.NoError(t, err)
+	assert.Equal(t, s, decoded)
+}
@@ -11,6 +11,8 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,6 +42,12 @@ func TestNullMount(t *testing.T) {
 	assert.NoError(t, err, "cannot write world.txt")
 }
 
+// TestReadonlyRoot asserts that the jail's root filesystem cannot be written.
+func TestReadonlyRoot(t *testing.T) {
+	err := os.WriteFile("/readonly.txt", []byte("output file"), 0644)
+	assert.ErrorIs(t, err, unix.EROFS, "root should be read-only")
+}
+
 func TestHostname(t *testing.T) {
 	hostname, err := os.Hostname()
 	assert.NoError(t, err, "failed to retrieve hostname")
@@ -120,3 +128,74 @@ func TestVnetConfigAndPing(t *testing.T) {
 	t.Logf("ping -c2 %s: %s", pingIP, string(out))
 	assert.NoError(t, err)
 }
+
+// TestUser prints the credentials the jail process runs with: the UID, the GID,
+// the supplementary groups, and the umask.
+func TestUser(t *testing.T) {
+	fmt.Println(os.Getuid())
+	fmt.Println(os.Getgid())
+	groups, err := os.Getgroups()
+	assert.NoError(t, err, "failed to retrieve groups")
+	fmt.Println(groups)
+	umask := unix.Umask(0)
+	unix.Umask(umask)
+	fmt.Printf("%#o\n", umask)
+}
+
+func TestCwd(t *testing.T) {
+	cwd, err := os.Getwd()
+	assert.NoError(t, err, "failed to retrieve working directory")
+	fmt.Println(cwd)
+}
+
+// TestRlimitNofile prints the soft and hard RLIMIT_NOFILE limits.
+func TestRlimitNofile(t *testing.T) {
+	var rlimit unix.Rlimit
+	err := unix.Getrlimit(unix.RLIMIT_NOFILE, &rlimit)
+	assert.NoError(t, err, "getrlimit")
+	fmt.Println(rlimit.Cur)
+	fmt.Println(rlimit.Max)
+}
+
+// TestHookState writes the state passed to a hook on STDIN to the file named by
+// TEST_HOOK_OUTPUT.
+func TestHookState(t *testing.T) {
+	state, err := io.ReadAll(os.Stdin)
+	require.NoError(t, err, "failed to read state")
+	err = os.WriteFile(os.Getenv("TEST_HOOK_OUTPUT"), state, 0644)
+	require.NoError(t, err, "failed to write state")
+}
+
+// sfArchived is the SF_ARCHIVED system file flag from <sys/stat.h>
+const sfArchived = 0x00010000
+
+// TestChflags sets and clears a system file flag, which requires allow.chflags.
+func TestChflags(t *testing.T) {
+	const path = "/chflags.txt"
+	err := os.WriteFile(path, []byte("flags"), 0644)
+	require.NoError(t, err, "failed to write file")
+	defer os.Remove(path)
+	err = unix.Chflags(path, sfArchived)
+	assert.NoError(t, err, "failed to set SF_ARCHIVED")
+	err = unix.Chflags(path, 0)
+	assert.NoError(t, err, "failed to clear flags")
+}
+
+// TestRawSocket opens a raw ICMP socket, which requires allow.raw_sockets.
+func TestRawSocket(t *testing.T) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_RAW, unix.IPPROTO_ICMP)
+	require.NoError(t, err, "failed to open raw socket")
+	unix.Close(fd)
+}
+
+// TestDevices asserts that the devices listed in TEST_DEVICES (separated by
+// commas) are present in /dev and that TEST_HIDDEN_DEVICE is not.
+func TestDevices(t *testing.T) {
+	for _, device := range strings.Split(os.Getenv("TEST_DEVICES"), ",") {
+		_, err := os.Stat(filepath.Join("/dev", device))
+		assert.NoError(t, err, "device %q should be visible", device)
+	}
+	hidden := filepath.Join("/dev", os.Getenv("TEST_HIDDEN_DEVICE"))
+	_, err := os.Stat(hidden)
+	assert.ErrorIs(t, err, fs.ErrNotExist, "device %q should be hidden", hidden)
+}
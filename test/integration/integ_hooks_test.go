@@ -4,13 +4,16 @@
 package integration
 
 import (
+	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 	"time"
 
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHooks(t *testing.T) {
@@ -22,10 +25,18 @@ func TestHooks(t *testing.T) {
 	}
 
 	spec.Hooks = &runtimespec.Hooks{
+		Prestart: []runtimespec.Hook{runtimespec.Hook{
+			Path: "/usr/bin/touch",
+			Args: []string{"/usr/bin/touch", filepath.Join(dir, "prestart")},
+		}},
 		CreateRuntime: []runtimespec.Hook{runtimespec.Hook{
 			Path: "/usr/bin/touch",
 			Args: []string{"/usr/bin/touch", filepath.Join(dir, "create-runtime")},
 		}},
+		Poststart: []runtimespec.Hook{runtimespec.Hook{
+			Path: "/usr/bin/touch",
+			Args: []string{"/usr/bin/touch", filepath.Join(dir, "poststart")},
+		}},
 		Poststop: []runtimespec.Hook{runtimespec.Hook{
 			Path: "/usr/bin/touch",
 			Args: []string{"/usr/bin/touch", filepath.Join(dir, "poststop")},
@@ -35,10 +46,29 @@ func TestHooks(t *testing.T) {
 	_, _, err := runExitingJail(t, "integ-test-hooks", spec, 500*time.Millisecond)
 	assert.NoError(t, err)
 
-	_, err = os.Stat(filepath.Join(dir, "create-runtime"))
-	assert.NoError(t, err)
-	_, err = os.Stat(filepath.Join(dir, "poststop"))
+	for _, name := range []string{"prestart", "create-runtime", "poststart", "poststop"} {
+		_, err = os.Stat(filepath.Join(dir, name))
+		assert.NoError(t, err, "%s hook should run", name)
+	}
+}
+
+func TestPoststartHookFailure(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.v", "-test.run", "TestHello"},
+	}
+	spec.Hooks = &runtimespec.Hooks{
+		Poststart: []runtimespec.Hook{runtimespec.Hook{
+			Path: "/usr/bin/false",
+			Args: []string{"/usr/bin/false"},
+		}},
+	}
+
+	// a failing poststart hook is logged but does not fail `runj start`
+	stdout, stderr, err := runExitingJail(t, "integ-test-poststart-failure", spec, 500*time.Millisecond)
 	assert.NoError(t, err)
+	assertJailPass(t, stdout, stderr)
 }
 
 func TestHookTimeout(t *testing.T) {
@@ -62,3 +92,76 @@ func TestHookTimeout(t *testing.T) {
 	assert.Error(t, err)
 	assert.Less(t, time.Duration(timeout)*time.Second, time.Since(start)*time.Second)
 }
+
+// insideHook returns a hook that runs the inside binary in the jail, writing
+// the state it receives to name in the /volume mount
+func insideHook(name string) runtimespec.Hook {
+	return runtimespec.Hook{
+		Path: "/integ-inside",
+		Args: []string{"/integ-inside", "-test.run", "TestHookState"},
+		Env:  []string{"TEST_HOOK_OUTPUT=" + filepath.Join("/volume", name)},
+	}
+}
+
+func TestContainerHooks(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+	volume := t.TempDir()
+	// createContainer hook paths resolve on the host
+	hostInside, err := filepath.Abs("bin/integ-inside")
+	require.NoError(t, err)
+	createContainer := insideHook("create-container")
+	createContainer.Path = hostInside
+
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.v", "-test.run", "TestHello"},
+	}
+	spec.Mounts = []runtimespec.Mount{{
+		Destination: "/volume",
+		Type:        "nullfs",
+		Source:      volume,
+	}}
+	spec.Hooks = &runtimespec.Hooks{
+		CreateContainer: []runtimespec.Hook{createContainer},
+		StartContainer:  []runtimespec.Hook{insideHook("start-container")},
+	}
+	spec.Annotations = map[string]string{"test": t.Name()}
+
+	_, _, err = runExitingJail(t, "integ-test-container-hooks", spec, 500*time.Millisecond)
+	assert.NoError(t, err)
+
+	for name, status := range map[string]string{
+		"create-container": "creating",
+		"start-container":  "created",
+	} {
+		b, err := os.ReadFile(filepath.Join(volume, name))
+		if !assert.NoError(t, err, "%s hook should run in the jail", name) {
+			continue
+		}
+		var st struct {
+			ID          string            `json:"id"`
+			Status      string            `json:"status"`
+			PID         int               `json:"pid"`
+			Annotations map[string]string `json:"annotations"`
+		}
+		require.NoError(t, json.Unmarshal(b, &st), "parse %s state", name)
+		assert.Equal(t, "integ-test-container-hooks", st.ID)
+		assert.Equal(t, status, st.Status, "%s status", name)
+		assert.NotZero(t, st.PID, "%s pid", name)
+		assert.Equal(t, t.Name(), st.Annotations["test"], "%s annotations", name)
+	}
+}
+
+func TestCreateContainerHookFailure(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.v", "-test.run", "TestHello"},
+	}
+	spec.Hooks = &runtimespec.Hooks{
+		CreateContainer: []runtimespec.Hook{{Path: "/nonexistent", Args: []string{"/nonexistent"}}},
+	}
+	const id = "integ-test-create-container-failure"
+	out, err := createJail(t, id, spec)
+	require.Error(t, err, "runj create should fail: %s", out)
+	assert.Contains(t, string(out), `createContainer hook "/nonexistent"`)
+	assert.Error(t, exec.Command("jls", "-j", id).Run(), "jail should be removed")
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.sbk.wtf/runj/demo"
 	"go.sbk.wtf/runj/internal/util"
+	runjspec "go.sbk.wtf/runj/runtimespec"
 )
 
 const (
@@ -437,6 +438,13 @@ func assertJailPass(t *testing.T, stdout, stderr []byte) {
 // on the outcome of create itself, such as validation rejections.  The bundle
 // and the jail are removed when the test ends, whether or not create succeeded.
 func createJail(t *testing.T, id string, spec runtimespec.Spec) ([]byte, error) {
+	t.Helper()
+	return createJailExt(t, id, spec, nil)
+}
+
+// createJailExt is createJail for a bundle that also includes ext as its
+// runj.ext.json, when ext is not nil.
+func createJailExt(t *testing.T, id string, spec runtimespec.Spec, ext *runjspec.FreeBSD) ([]byte, error) {
 	t.Helper()
 	dir, err := os.MkdirTemp("", "runj-integ-test-"+strings.ReplaceAll(t.Name(), "/", "-"))
 	require.NoError(t, err)
@@ -446,11 +454,29 @@ func createJail(t *testing.T, id string, spec runtimespec.Spec) ([]byte, error)
 	configJSON, err := json.Marshal(spec)
 	require.NoError(t, err, "marshal config")
 	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), configJSON, 0644), "write config")
+	if ext != nil {
+		extJSON, err := json.Marshal(ext)
+		require.NoError(t, err, "marshal runj.ext.json")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "runj.ext.json"), extJSON, 0644), "write runj.ext.json")
+	}
 
 	exec.Command("runj", "delete", id).Run() // best-effort: clear any leftover
 	t.Cleanup(func() { exec.Command("runj", "delete", id).Run() })
 
-	return exec.Command("runj", "create", id, dir).CombinedOutput()
+	// Collect output through a file rather than a pipe: runj-entrypoint
+	// inherits create's stdio and keeps it open after create exits, so
+	// waiting for a pipe to close would block until the container exits.
+	outPath := filepath.Join(dir, "create-output")
+	outFile, err := os.Create(outPath)
+	require.NoError(t, err, "create output file")
+	cmd := exec.Command("runj", "create", id, dir)
+	cmd.Stdout = outFile
+	cmd.Stderr = outFile
+	err = cmd.Run()
+	outFile.Close()
+	out, readErr := os.ReadFile(outPath)
+	require.NoError(t, readErr, "read output file")
+	return out, err
 }
 
 // runExitingJail is a helper that takes a spec as input, sets up a bundle
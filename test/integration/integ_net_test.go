@@ -5,6 +5,7 @@ package integration
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -19,6 +20,8 @@ import (
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"go.sbk.wtf/runj/stats"
 )
 
 func TestHostIPv4Network(t *testing.T) {
@@ -101,6 +104,76 @@ func TestJailIP6(t *testing.T) {
 	}
 }
 
+func TestJailInterfaceAliases(t *testing.T) {
+	// 127.0.0.1 is already configured on lo0, so runj must neither add nor
+	// remove it
+	const iface, addr, existing = "lo0", "127.0.0.42", "127.0.0.1"
+	const id = "integ-test-interface"
+
+	out, err := createJail(t, id, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+		FreeBSD: &runtimespec.FreeBSD{
+			Jail: &runtimespec.FreeBSDJail{
+				Ip4:       runtimespec.FreeBSDShareNew,
+				Ip4Addr:   []string{existing, addr},
+				Interface: iface,
+			},
+		},
+	})
+	require.NoError(t, err, "runj create: %s", out)
+	assert.Contains(t, interfaceAddrs(t, iface), addr, "alias should be added")
+
+	out, err = exec.Command("runj", "delete", id).CombinedOutput()
+	require.NoError(t, err, "runj delete: %s", out)
+	addrs := interfaceAddrs(t, iface)
+	assert.NotContains(t, addrs, addr, "alias should be removed")
+	assert.Contains(t, addrs, existing, "pre-existing address should be kept")
+}
+
+// interfaceAddrs returns the addresses configured on the host interface
+func interfaceAddrs(t *testing.T, iface string) []string {
+	t.Helper()
+	i, err := net.InterfaceByName(iface)
+	require.NoError(t, err, "interface %s", iface)
+	addrs, err := i.Addrs()
+	require.NoError(t, err, "interface %s addresses", iface)
+	var result []string
+	for _, a := range addrs {
+		if ipnet, ok := a.(*net.IPNet); ok {
+			result = append(result, ipnet.IP.String())
+		}
+	}
+	return result
+}
+
+func TestEventsVNetInterfaces(t *testing.T) {
+	skipWithoutRacct(t)
+	const id = "integ-test-events-vnet"
+	_, epairB := setupEpairBridge(t, "172.31.254.1", "24")
+	out, err := createJail(t, id, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+		FreeBSD: &runtimespec.FreeBSD{
+			Jail: &runtimespec.FreeBSDJail{
+				Vnet:           runtimespec.FreeBSDShareNew,
+				VnetInterfaces: []string{epairB},
+			},
+		},
+	})
+	require.NoError(t, err, "runj create: %s", out)
+
+	out, err = exec.Command("runj", "events", "--stats", id).Output()
+	require.NoError(t, err, "runj events --stats")
+	var e struct {
+		Data stats.Stats `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(out, &e), "events: %s", out)
+	var names []string
+	for _, iface := range e.Data.Network {
+		names = append(names, iface.Name)
+	}
+	assert.ElementsMatch(t, []string{"lo0", epairB}, names)
+}
+
 func TestVNetBridge(t *testing.T) {
 	// TODO: IPAM
 	bridgeAddr := "172.31.255.1"
@@ -4,6 +4,7 @@
 package integration
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -17,6 +18,9 @@ import (
 	runtimespec "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"go.sbk.wtf/runj/oci"
+	runjspec "go.sbk.wtf/runj/runtimespec"
 )
 
 func TestCreateDelete(t *testing.T) {
@@ -184,6 +188,67 @@ func TestStateOCIVersion(t *testing.T) {
 	assert.Equal(t, ociVersion, st.OCIVersion, "state should report the bundle's ociVersion")
 }
 
+func TestRoot(t *testing.T) {
+	dir, err := os.MkdirTemp("", "runj-integ-test-"+t.Name())
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "root"), 0755), "create root dir")
+	configJSON, err := json.Marshal(runtimespec.Spec{Process: &runtimespec.Process{}})
+	require.NoError(t, err, "marshal config")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), configJSON, 0644), "write config")
+
+	root := t.TempDir()
+	const id = "integ-test-root"
+	exec.Command("runj", "--root", root, "delete", id).Run() // best-effort: clear any leftover
+	t.Cleanup(func() { exec.Command("runj", "--root", root, "delete", id).Run() })
+
+	out, err := exec.Command("runj", "--root", root, "create", id, dir).CombinedOutput()
+	require.NoError(t, err, "runj create: %s", out)
+	assert.FileExists(t, filepath.Join(root, id, "state.json"), "state should be stored under the root")
+
+	out, err = exec.Command("runj", "list", "--quiet").Output()
+	require.NoError(t, err, "runj list")
+	assert.NotContains(t, strings.Fields(string(out)), id, "default root should not list the container")
+
+	cmd := exec.Command("runj", "list", "--quiet")
+	cmd.Env = append(os.Environ(), "RUNJ_ROOT="+root)
+	out, err = cmd.Output()
+	require.NoError(t, err, "runj list")
+	assert.Equal(t, []string{id}, strings.Fields(string(out)), "RUNJ_ROOT should select the root")
+
+	// jail names are not scoped by the root
+	other := t.TempDir()
+	out, err = exec.Command("runj", "--root", other, "create", id, dir).CombinedOutput()
+	require.Error(t, err, "runj create should fail for an ID in use under another root: %s", out)
+	assert.Contains(t, string(out), "already exists")
+	assert.NoDirExists(t, filepath.Join(other, id))
+
+	out, err = exec.Command("runj", "--root", root, "delete", id).CombinedOutput()
+	require.NoError(t, err, "runj delete: %s", out)
+	assert.NoDirExists(t, filepath.Join(root, id))
+}
+
+func TestLogJSON(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "log.json")
+	const id = "integ-test-log-missing"
+	out, err := exec.Command("runj", "--log", logPath, "--log-format", "json", "pause", id).CombinedOutput()
+	require.Error(t, err, "runj pause should fail for a missing container: %s", out)
+
+	b, err := os.ReadFile(logPath)
+	require.NoError(t, err, "read log")
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 1, "log: %s", b)
+	var entry struct {
+		Level string    `json:"level"`
+		Msg   string    `json:"msg"`
+		Time  time.Time `json:"time"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry), "log: %s", b)
+	assert.Equal(t, "error", entry.Level)
+	assert.Contains(t, entry.Msg, id)
+	assert.WithinDuration(t, time.Now(), entry.Time, time.Minute)
+}
+
 func TestJailHello(t *testing.T) {
 	spec := setupSimpleExitingJail(t)
 
@@ -243,6 +308,497 @@ func TestJailNullMount(t *testing.T) {
 	}
 }
 
+func TestJailReadonlyRoot(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+
+	volume := t.TempDir()
+	err := os.WriteFile(filepath.Join(volume, "hello.txt"), []byte("input file"), 0644)
+	require.NoError(t, err, "input file")
+
+	spec.Root.Readonly = true
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.run", "TestReadonlyRoot|TestNullMount"},
+	}
+	spec.Mounts = []runtimespec.Mount{{
+		Destination: "/volume",
+		Type:        "nullfs",
+		Source:      volume,
+	}}
+	stdout, stderr, err := runExitingJail(t, "integ-test-readonly", spec, 500*time.Millisecond)
+	assert.NoError(t, err)
+	assertJailPass(t, stdout, stderr)
+	output, err := os.ReadFile(filepath.Join(volume, "world.txt"))
+	assert.NoError(t, err, "failed to read world.txt")
+	assert.Equal(t, "output file", string(output))
+	_, err = os.Stat(filepath.Join(spec.Root.Path, "readonly.txt"))
+	assert.ErrorIs(t, err, os.ErrNotExist, "root should not be written")
+	if t.Failed() {
+		t.Log("STDOUT:", string(stdout))
+	}
+}
+
+func TestJailAllow(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.run", "TestChflags|TestRawSocket"},
+	}
+	spec.FreeBSD = &runtimespec.FreeBSD{
+		Jail: &runtimespec.FreeBSDJail{
+			Ip4: runtimespec.FreeBSDShareInherit,
+			Allow: &runtimespec.FreeBSDJailAllow{
+				Chflags:    true,
+				RawSockets: true,
+			},
+		},
+	}
+	stdout, stderr, err := runExitingJail(t, "integ-test-allow", spec, 500*time.Millisecond)
+	assert.NoError(t, err)
+	assertJailPass(t, stdout, stderr)
+	if t.Failed() {
+		t.Log("STDOUT:", string(stdout))
+	}
+}
+
+func TestJailAllowMountEnforceStatfs(t *testing.T) {
+	spec := runtimespec.Spec{
+		Process: &runtimespec.Process{},
+		FreeBSD: &runtimespec.FreeBSD{
+			Jail: &runtimespec.FreeBSDJail{
+				Allow: &runtimespec.FreeBSDJailAllow{Mount: []string{"tmpfs"}},
+			},
+		},
+	}
+	out, err := createJail(t, "integ-test-allow-mount", spec)
+	require.Error(t, err, "runj create should reject allow.mount without enforceStatfs: %s", out)
+	assert.Contains(t, string(out), "allow.mount requires enforce_statfs to be 0 or 1")
+}
+
+func TestJailDevices(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.run", "TestDevices"},
+		Env:  []string{"TEST_DEVICES=null,console", "TEST_HIDDEN_DEVICE=mem"},
+	}
+	spec.Mounts = []runtimespec.Mount{{
+		Destination: "/dev",
+		Type:        "devfs",
+		Source:      "devfs",
+		Options:     []string{"ruleset=4"},
+	}}
+	spec.FreeBSD = &runtimespec.FreeBSD{
+		Devices: []runtimespec.FreeBSDDevice{{Path: "console"}},
+	}
+	stdout, stderr, err := runExitingJail(t, "integ-test-devices", spec, 500*time.Millisecond)
+	assert.NoError(t, err)
+	assertJailPass(t, stdout, stderr)
+	if t.Failed() {
+		t.Log("STDOUT:", string(stdout))
+	}
+}
+
+func TestJailDevfsRulesetRemoved(t *testing.T) {
+	spec := runtimespec.Spec{
+		Process: &runtimespec.Process{},
+		FreeBSD: &runtimespec.FreeBSD{
+			Devices: []runtimespec.FreeBSDDevice{{Path: "console"}},
+		},
+	}
+	const id = "integ-test-devfs-ruleset"
+	out, err := createJail(t, id, spec)
+	require.NoError(t, err, "runj create: %s", out)
+
+	out, err = exec.Command("jls", "-j", id, "devfs_ruleset").Output()
+	require.NoError(t, err, "jls")
+	ruleset := strings.TrimSpace(string(out))
+	n, err := strconv.Atoi(ruleset)
+	require.NoError(t, err, "parse devfs_ruleset %q", ruleset)
+	assert.GreaterOrEqual(t, n, 1000, "jail should use a dedicated ruleset")
+
+	out, err = exec.Command("runj", "delete", id).CombinedOutput()
+	require.NoError(t, err, "runj delete: %s", out)
+	out, err = exec.Command("devfs", "rule", "showsets").Output()
+	require.NoError(t, err, "devfs rule showsets")
+	assert.NotContains(t, strings.Fields(string(out)), ruleset, "ruleset should be deleted")
+}
+
+func TestJailParent(t *testing.T) {
+	const parent, child = "integ-test-parent", "integ-test-parent-child"
+	out, err := createJail(t, parent, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+	})
+	require.NoError(t, err, "runj create parent: %s", out)
+	childrenMax := func() string {
+		t.Helper()
+		out, err := exec.Command("jls", "-j", parent, "children.max").Output()
+		require.NoError(t, err, "jls")
+		return strings.TrimSpace(string(out))
+	}
+	assert.Equal(t, "0", childrenMax())
+	out, err = createJail(t, child, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+		FreeBSD: &runtimespec.FreeBSD{
+			Jail: &runtimespec.FreeBSDJail{Parent: parent},
+		},
+	})
+	require.NoError(t, err, "runj create child: %s", out)
+
+	out, err = exec.Command("jls", "-j", parent+"."+child, "name").Output()
+	require.NoError(t, err, "jls")
+	assert.Equal(t, parent+"."+child, strings.TrimSpace(string(out)))
+	assert.Equal(t, "1", childrenMax(), "children.max should be raised for the child")
+
+	out, err = exec.Command("runj", "delete", parent).CombinedOutput()
+	assert.Error(t, err, "a parent with a child container should not be deleted")
+	assert.Contains(t, string(out), "child jails")
+
+	out, err = exec.Command("runj", "delete", child).CombinedOutput()
+	assert.NoError(t, err, "runj delete child: %s", out)
+	assert.Equal(t, "0", childrenMax(), "children.max should be restored")
+	out, err = exec.Command("runj", "delete", parent).CombinedOutput()
+	assert.NoError(t, err, "runj delete parent: %s", out)
+}
+
+func TestJailSysV(t *testing.T) {
+	const id = "integ-test-sysv"
+	out, err := createJail(t, id, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+		FreeBSD: &runtimespec.FreeBSD{
+			Jail: &runtimespec.FreeBSDJail{
+				SysVMsg: runtimespec.FreeBSDShareNew,
+				SysVSem: runtimespec.FreeBSDShareInherit,
+				SysVShm: runtimespec.FreeBSDShareDisable,
+			},
+		},
+	})
+	require.NoError(t, err, "runj create: %s", out)
+
+	out, err = exec.Command("jls", "-j", id, "sysvmsg", "sysvsem", "sysvshm").Output()
+	require.NoError(t, err, "jls")
+	assert.Equal(t, []string{"new", "inherit", "disable"}, strings.Fields(string(out)))
+}
+
+// skipWithoutRacct skips tests that need rctl(8), which requires resource
+// accounting to be enabled
+func skipWithoutRacct(t *testing.T) {
+	t.Helper()
+	out, err := exec.Command("sysctl", "-n", "kern.racct.enable").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "1" {
+		t.Skip("racct is not enabled")
+	}
+}
+
+func TestJailResourceLimits(t *testing.T) {
+	skipWithoutRacct(t)
+	const id = "integ-test-rctl"
+	out, err := createJailExt(t, id, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+	}, &runjspec.FreeBSD{
+		Resources: &runjspec.FreeBSDResources{
+			Limits: []runjspec.FreeBSDResourceLimit{
+				{Resource: "maxproc", Amount: 10},
+				{Resource: "writebps", Amount: 1 << 20},
+			},
+		},
+	})
+	require.NoError(t, err, "runj create: %s", out)
+
+	out, err = exec.Command("rctl", "jail:"+id).Output()
+	require.NoError(t, err, "rctl")
+	assert.ElementsMatch(t, []string{
+		"jail:" + id + ":maxproc:deny=10",
+		"jail:" + id + ":writebps:throttle=1048576",
+	}, strings.Fields(string(out)))
+
+	out, err = exec.Command("runj", "delete", id).CombinedOutput()
+	require.NoError(t, err, "runj delete: %s", out)
+	out, err = exec.Command("rctl", "jail:"+id).Output()
+	require.NoError(t, err, "rctl")
+	assert.Empty(t, strings.TrimSpace(string(out)), "rules should be removed")
+}
+
+func TestJailLinuxResources(t *testing.T) {
+	skipWithoutRacct(t)
+	const id = "integ-test-linux-resources"
+	memory, pids := int64(256<<20), int64(20)
+	out, err := createJail(t, id, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+		Linux: &runtimespec.Linux{
+			Resources: &runtimespec.LinuxResources{
+				Memory: &runtimespec.LinuxMemory{Limit: &memory},
+				Pids:   &runtimespec.LinuxPids{Limit: &pids},
+			},
+		},
+	})
+	require.NoError(t, err, "runj create: %s", out)
+
+	out, err = exec.Command("rctl", "jail:"+id).Output()
+	require.NoError(t, err, "rctl")
+	assert.ElementsMatch(t, []string{
+		"jail:" + id + ":memoryuse:deny=268435456",
+		"jail:" + id + ":maxproc:deny=20",
+	}, strings.Fields(string(out)))
+}
+
+func TestJailUpdate(t *testing.T) {
+	skipWithoutRacct(t)
+	const id = "integ-test-update"
+	out, err := createJailExt(t, id, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+	}, &runjspec.FreeBSD{
+		Resources: &runjspec.FreeBSDResources{
+			Limits: []runjspec.FreeBSDResourceLimit{
+				{Resource: "maxproc", Amount: 10},
+				{Resource: "openfiles", Amount: 100},
+			},
+		},
+	})
+	require.NoError(t, err, "runj create: %s", out)
+
+	cmd := exec.Command("runj", "update", id, "--resources", "-")
+	cmd.Stdin = strings.NewReader(`{
+		"pids": {"limit": 30},
+		"limits": [{"resource": "readbps", "amount": 1048576}],
+		"jail": {"enforceStatfs": 1}
+	}`)
+	out, err = cmd.CombinedOutput()
+	require.NoError(t, err, "runj update: %s", out)
+
+	out, err = exec.Command("rctl", "jail:"+id).Output()
+	require.NoError(t, err, "rctl")
+	assert.ElementsMatch(t, []string{
+		"jail:" + id + ":maxproc:deny=30",
+		"jail:" + id + ":openfiles:deny=100",
+		"jail:" + id + ":readbps:throttle=1048576",
+	}, strings.Fields(string(out)))
+	out, err = exec.Command("jls", "-j", id, "enforce_statfs").Output()
+	require.NoError(t, err, "jls")
+	assert.Equal(t, "1", strings.TrimSpace(string(out)))
+
+	// a throttle rule is replaced rather than added beside the current one
+	cmd = exec.Command("runj", "update", id, "--resources", "-")
+	cmd.Stdin = strings.NewReader(`{"limits": [{"resource": "readbps", "amount": 2097152}]}`)
+	out, err = cmd.CombinedOutput()
+	require.NoError(t, err, "runj update: %s", out)
+	out, err = exec.Command("rctl", "jail:"+id+":readbps").Output()
+	require.NoError(t, err, "rctl")
+	assert.Equal(t, []string{"jail:" + id + ":readbps:throttle=2097152"}, strings.Fields(string(out)))
+
+	cmd = exec.Command("runj", "update", id, "--resources", "-")
+	cmd.Stdin = strings.NewReader(`{"memory": {"swap": 1048576}}`)
+	out, err = cmd.CombinedOutput()
+	require.Error(t, err, "runj update should fail: %s", out)
+	assert.Contains(t, string(out), "resources.memory.swap")
+
+	out, err = exec.Command("runj", "delete", id).CombinedOutput()
+	require.NoError(t, err, "runj delete: %s", out)
+	out, err = exec.Command("rctl", "jail:"+id).Output()
+	require.NoError(t, err, "rctl")
+	assert.Empty(t, strings.TrimSpace(string(out)), "rules should be removed")
+}
+
+func TestList(t *testing.T) {
+	const id = "integ-test-list"
+	out, err := createJail(t, id, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+	})
+	require.NoError(t, err, "runj create: %s", out)
+
+	out, err = exec.Command("runj", "list", "--format", "json").Output()
+	require.NoError(t, err, "runj list")
+	var entries []struct {
+		ID      string    `json:"id"`
+		Status  string    `json:"status"`
+		Created time.Time `json:"created"`
+		JID     int       `json:"jid"`
+	}
+	require.NoError(t, json.Unmarshal(out, &entries), "list: %s", out)
+	out, err = exec.Command("jls", "-j", id, "jid").Output()
+	require.NoError(t, err, "jls")
+	jid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	require.NoError(t, err, "jls: %s", out)
+	found := false
+	for _, e := range entries {
+		if e.ID != id {
+			continue
+		}
+		found = true
+		assert.Equal(t, "created", e.Status)
+		assert.WithinDuration(t, time.Now(), e.Created, time.Minute)
+		assert.Equal(t, jid, e.JID)
+	}
+	assert.True(t, found, "container should be listed: %s", out)
+
+	out, err = exec.Command("runj", "list").Output()
+	require.NoError(t, err, "runj list")
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	assert.Equal(t, []string{"ID", "PID", "STATUS", "BUNDLE", "CREATED", "JID"}, strings.Fields(lines[0]))
+	assert.Contains(t, string(out), id)
+
+	out, err = exec.Command("runj", "list", "--quiet").Output()
+	require.NoError(t, err, "runj list --quiet")
+	assert.Contains(t, strings.Fields(string(out)), id)
+}
+
+func TestPs(t *testing.T) {
+	const id = "integ-test-ps"
+	out, err := createJail(t, id, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+	})
+	require.NoError(t, err, "runj create: %s", out)
+
+	// before start, the only process in the jail is runj-entrypoint waiting
+	// for `runj start`
+	out, err = exec.Command("runj", "ps", "--format", "json", id).Output()
+	require.NoError(t, err, "runj ps")
+	var processes []struct {
+		PID     int    `json:"pid"`
+		Command string `json:"command"`
+	}
+	require.NoError(t, json.Unmarshal(out, &processes), "ps: %s", out)
+	require.Len(t, processes, 1, "ps: %s", out)
+	assert.Positive(t, processes[0].PID)
+	assert.Contains(t, processes[0].Command, "runj-entrypoint")
+
+	out, err = exec.Command("runj", "ps", id).Output()
+	require.NoError(t, err, "runj ps")
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	require.Len(t, lines, 2, "ps: %s", out)
+	assert.Equal(t, []string{"PID", "TT", "STAT", "TIME", "COMMAND"}, strings.Fields(lines[0]))
+	assert.Equal(t, strconv.Itoa(processes[0].PID), strings.Fields(lines[1])[0])
+}
+
+func TestPauseResume(t *testing.T) {
+	spec := setupFullExitingJail(t)
+	spec.Process = &runtimespec.Process{Args: []string{"/bin/sleep", "60"}}
+	const id = "integ-test-pause"
+	out, err := createJail(t, id, spec)
+	require.NoError(t, err, "runj create: %s", out)
+
+	out, err = exec.Command("runj", "pause", id).CombinedOutput()
+	require.Error(t, err, "runj pause should fail before start: %s", out)
+
+	out, err = exec.Command("runj", "start", id).CombinedOutput()
+	require.NoError(t, err, "runj start: %s", out)
+
+	status := func() string {
+		t.Helper()
+		out, err := exec.Command("runj", "state", id).Output()
+		require.NoError(t, err, "runj state")
+		var st struct {
+			Status string `json:"status"`
+		}
+		require.NoError(t, json.Unmarshal(out, &st), "parse state output")
+		return st.Status
+	}
+	processState := func() string {
+		t.Helper()
+		out, err := exec.Command("runj", "ps", "--format", "json", id).Output()
+		require.NoError(t, err, "runj ps")
+		var processes []struct {
+			State string `json:"state"`
+		}
+		require.NoError(t, json.Unmarshal(out, &processes), "ps: %s", out)
+		require.Len(t, processes, 1, "ps: %s", out)
+		return processes[0].State
+	}
+
+	out, err = exec.Command("runj", "pause", id).CombinedOutput()
+	require.NoError(t, err, "runj pause: %s", out)
+	assert.Equal(t, "paused", status())
+	assert.True(t, strings.HasPrefix(processState(), "T"), "process should be stopped")
+
+	out, err = exec.Command("runj", "delete", id).CombinedOutput()
+	require.Error(t, err, "runj delete should fail while paused: %s", out)
+	assert.Contains(t, string(out), "is paused")
+
+	out, err = exec.Command("runj", "resume", id).CombinedOutput()
+	require.NoError(t, err, "runj resume: %s", out)
+	assert.Equal(t, "running", status())
+	assert.False(t, strings.HasPrefix(processState(), "T"), "process should be continued")
+
+	out, err = exec.Command("runj", "resume", id).CombinedOutput()
+	require.Error(t, err, "runj resume should fail while running: %s", out)
+
+	// a paused container can still be killed
+	out, err = exec.Command("runj", "pause", id).CombinedOutput()
+	require.NoError(t, err, "runj pause: %s", out)
+	out, err = exec.Command("runj", "kill", id, "KILL").CombinedOutput()
+	require.NoError(t, err, "runj kill: %s", out)
+	assert.Eventually(t, func() bool { return status() == "stopped" }, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestEvents(t *testing.T) {
+	skipWithoutRacct(t)
+	const id = "integ-test-events"
+	out, err := createJail(t, id, runtimespec.Spec{
+		Process: &runtimespec.Process{Args: []string{"/bin/sh"}},
+	})
+	require.NoError(t, err, "runj create: %s", out)
+
+	type event struct {
+		Type string          `json:"type"`
+		ID   string          `json:"id"`
+		Data json.RawMessage `json:"data"`
+	}
+	var stats struct {
+		Memory struct {
+			Usage uint64 `json:"usage"`
+		} `json:"memory"`
+		Pids struct {
+			Current uint64 `json:"current"`
+		} `json:"pids"`
+	}
+
+	out, err = exec.Command("runj", "events", "--stats", id).Output()
+	require.NoError(t, err, "runj events --stats")
+	var e event
+	require.NoError(t, json.Unmarshal(out, &e), "events: %s", out)
+	assert.Equal(t, "stats", e.Type)
+	assert.Equal(t, id, e.ID)
+	require.NoError(t, json.Unmarshal(e.Data, &stats), "stats: %s", e.Data)
+	// runj-entrypoint waits in the jail for `runj start`
+	assert.Equal(t, uint64(1), stats.Pids.Current)
+	assert.Positive(t, stats.Memory.Usage)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "runj", "events", "--interval", "100ms", id)
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err, "stdout pipe")
+	require.NoError(t, cmd.Start(), "runj events")
+	defer cmd.Wait()
+	defer cancel()
+	dec := json.NewDecoder(stdout)
+	var types []string
+	for len(types) < 3 {
+		require.NoError(t, dec.Decode(&e), "decode event")
+		types = append(types, e.Type)
+		if e.Type == "status" {
+			var s struct {
+				Status string `json:"status"`
+			}
+			require.NoError(t, json.Unmarshal(e.Data, &s))
+			assert.Equal(t, "created", s.Status)
+		}
+	}
+	// the status is reported once, followed by stats at every interval
+	assert.Equal(t, []string{"status", "stats", "stats"}, types)
+}
+
+func TestFeatures(t *testing.T) {
+	out, err := exec.Command("runj", "features").Output()
+	require.NoError(t, err, "runj features")
+	var features oci.Features
+	require.NoError(t, json.Unmarshal(out, &features), "features: %s", out)
+	assert.Equal(t, runtimespec.Version, features.OCIVersionMax)
+	assert.Contains(t, features.Hooks, "createRuntime")
+	require.NotNil(t, features.FreeBSD)
+	assert.Contains(t, features.FreeBSD.Jail, "vnet")
+	assert.Contains(t, features.FreeBSD.Resources, "maxproc")
+}
+
 func TestJailHostname(t *testing.T) {
 	hostname := fmt.Sprintf("%s.example", t.Name())
 
@@ -348,3 +904,205 @@ func TestJailDomainname(t *testing.T) {
 		t.Log("STDOUT:", string(stdout))
 	}
 }
+
+func TestJailUser(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+	// the jail root is a private temporary directory; open it up so that the
+	// unprivileged user can reach the test binary
+	require.NoError(t, os.Chmod(spec.Root.Path, 0755), "chmod root")
+
+	umask := uint32(0o027)
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.run", "TestUser"},
+		User: runtimespec.User{
+			UID:            1001,
+			GID:            1002,
+			AdditionalGids: []uint32{1003, 1004},
+			Umask:          &umask,
+		},
+	}
+
+	stdout, stderr, err := runExitingJail(t, "integ-test-user", spec, 500*time.Millisecond)
+	assert.NoError(t, err)
+	assertJailPass(t, stdout, stderr)
+	lines := strings.Split(string(stdout), "\n")
+	require.Len(t, lines, 6, "should be exactly 6 lines of output")
+	assert.Equal(t, "1001", lines[0], "uid should match")
+	assert.Equal(t, "1002", lines[1], "gid should match")
+	for _, gid := range []string{"1003", "1004"} {
+		assert.Contains(t, strings.Fields(strings.Trim(lines[2], "[]")), gid, "groups should include additional gid")
+	}
+	assert.NotContains(t, strings.Fields(strings.Trim(lines[2], "[]")), "0", "groups should not include root's groups")
+	assert.Equal(t, "027", lines[3], "umask should match")
+	if t.Failed() {
+		t.Log("STDOUT:", string(stdout))
+	}
+}
+
+// writeUserDatabases writes minimal passwd(5) and group(5) files into a jail
+// root.
+func writeUserDatabases(t *testing.T, root string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "etc"), 0755), "create etc")
+	passwd := "root:*:0:0:Charlie &:/root:/bin/sh\n" +
+		"app:*:1001:1002:Application:/nonexistent:/usr/sbin/nologin\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, "etc", "passwd"), []byte(passwd), 0644), "write passwd")
+	group := "wheel:*:0:root\n" +
+		"app:*:1002:\n" +
+		"video:*:44:app\n" +
+		"nogroup:*:65533:\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, "etc", "group"), []byte(group), 0644), "write group")
+}
+
+func TestJailUsername(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+	require.NoError(t, os.Chmod(spec.Root.Path, 0755), "chmod root")
+	writeUserDatabases(t, spec.Root.Path)
+
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.run", "TestUser"},
+		User: runtimespec.User{Username: "app:nogroup"},
+	}
+
+	stdout, stderr, err := runExitingJail(t, "integ-test-username", spec, 500*time.Millisecond)
+	assert.NoError(t, err)
+	assertJailPass(t, stdout, stderr)
+	lines := strings.Split(string(stdout), "\n")
+	require.Len(t, lines, 6, "should be exactly 6 lines of output")
+	assert.Equal(t, "1001", lines[0], "uid should match")
+	assert.Equal(t, "65533", lines[1], "gid should match the named group")
+	assert.Contains(t, strings.Fields(strings.Trim(lines[2], "[]")), "44", "groups should include group membership")
+	if t.Failed() {
+		t.Log("STDOUT:", string(stdout))
+	}
+}
+
+func TestJailUnknownUsername(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+	writeUserDatabases(t, spec.Root.Path)
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.run", "TestUser"},
+		User: runtimespec.User{Username: "postgres"},
+	}
+	id := "integ-test-unknown-username"
+	out, err := createJail(t, id, spec)
+	require.NoError(t, err, "runj create: %s", out)
+
+	out, err = exec.Command("runj", "start", id).CombinedOutput()
+	require.Error(t, err, "runj start should fail for an unknown user: %s", out)
+	assert.Contains(t, string(out), `unknown user "postgres"`, "error should name the user")
+}
+
+func TestJailCwd(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(spec.Root.Path, "srv", "service"), 0755), "create cwd")
+
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.run", "TestCwd"},
+		Cwd:  "/srv/service",
+	}
+
+	stdout, stderr, err := runExitingJail(t, "integ-test-cwd", spec, 500*time.Millisecond)
+	assert.NoError(t, err)
+	assertJailPass(t, stdout, stderr)
+	lines := strings.Split(string(stdout), "\n")
+	assert.Len(t, lines, 3, "should be exactly 3 lines of output")
+	assert.Equal(t, "/srv/service", lines[0], "cwd should match")
+	if t.Failed() {
+		t.Log("STDOUT:", string(stdout))
+	}
+}
+
+func TestJailCwdMissing(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.run", "TestCwd"},
+		Cwd:  "/app",
+	}
+	id := "integ-test-cwd-missing"
+	out, err := createJail(t, id, spec)
+	require.NoError(t, err, "runj create: %s", out)
+
+	out, err = exec.Command("runj", "start", id).CombinedOutput()
+	require.Error(t, err, "runj start should fail for a missing cwd: %s", out)
+	assert.Contains(t, string(out), `"/app" does not exist in the jail`, "error should name the directory")
+}
+
+func TestJailCwdRelative(t *testing.T) {
+	spec := runtimespec.Spec{
+		Process: &runtimespec.Process{Cwd: "app"},
+	}
+	out, err := createJail(t, "integ-test-cwd-relative", spec)
+	require.Error(t, err, "runj create should reject a relative cwd: %s", out)
+	assert.Contains(t, string(out), "must be an absolute path", "error should explain the rejection")
+}
+
+func TestJailRlimits(t *testing.T) {
+	spec := setupSimpleExitingJail(t)
+	spec.Process = &runtimespec.Process{
+		Args: []string{"/integ-inside", "-test.run", "TestRlimitNofile"},
+		Rlimits: []runtimespec.POSIXRlimit{{
+			Type: "RLIMIT_NOFILE",
+			Soft: 512,
+			Hard: 1024,
+		}},
+	}
+
+	stdout, stderr, err := runExitingJail(t, "integ-test-rlimits", spec, 500*time.Millisecond)
+	assert.NoError(t, err)
+	assertJailPass(t, stdout, stderr)
+	lines := strings.Split(string(stdout), "\n")
+	require.Len(t, lines, 4, "should be exactly 4 lines of output")
+	assert.Equal(t, "512", lines[0], "soft limit should match")
+	assert.Equal(t, "1024", lines[1], "hard limit should match")
+	if t.Failed() {
+		t.Log("STDOUT:", string(stdout))
+	}
+}
+
+func TestJailRlimitsUnsupported(t *testing.T) {
+	spec := runtimespec.Spec{
+		Process: &runtimespec.Process{
+			Rlimits: []runtimespec.POSIXRlimit{{Type: "RLIMIT_RTPRIO", Soft: 1, Hard: 1}},
+		},
+	}
+	out, err := createJail(t, "integ-test-rlimits-unsupported", spec)
+	require.Error(t, err, "runj create should reject a Linux-only rlimit: %s", out)
+	assert.Contains(t, string(out), `unsupported type "RLIMIT_RTPRIO"`, "error should name the rlimit")
+}
+
+func TestJailUnsupportedConfig(t *testing.T) {
+	spec := runtimespec.Spec{
+		Process: &runtimespec.Process{NoNewPrivileges: true},
+		Linux: &runtimespec.Linux{
+			Namespaces: []runtimespec.LinuxNamespace{{Type: runtimespec.PIDNamespace}},
+		},
+	}
+	out, err := createJail(t, "integ-test-unsupported", spec)
+	require.Error(t, err, "runj create should reject unsupported configuration: %s", out)
+	assert.Contains(t, string(out), "process.noNewPrivileges: not supported")
+	assert.Contains(t, string(out), "linux.namespaces: not supported")
+}
+
+func TestJailUnsupportedConfigPermissive(t *testing.T) {
+	spec := runtimespec.Spec{
+		Process: &runtimespec.Process{},
+		Linux: &runtimespec.Linux{
+			Namespaces: []runtimespec.LinuxNamespace{{Type: runtimespec.PIDNamespace}},
+		},
+		Annotations: map[string]string{oci.PermissiveAnnotation: "true"},
+	}
+	out, err := createJail(t, "integ-test-unsupported-permissive", spec)
+	require.NoError(t, err, "runj create should warn in permissive mode: %s", out)
+	assert.Contains(t, string(out), "warning: unsupported configuration: linux.namespaces: not supported")
+}
+
+func TestJailOCIVersionMajor(t *testing.T) {
+	spec := runtimespec.Spec{
+		Version: "2.0.0",
+		Process: &runtimespec.Process{},
+	}
+	out, err := createJail(t, "integ-test-ociversion-major", spec)
+	require.Error(t, err, "runj create should reject a different major version: %s", out)
+	assert.Contains(t, string(out), `ociVersion "2.0.0"`)
+}